@@ -1,26 +1,102 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"game_web_server/generated"
+	"game_web_server/pkg/core"
+	"game_web_server/pkg/entities"
+	"game_web_server/pkg/protocol"
+	"game_web_server/pkg/schema"
+	"game_web_server/schemes"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"github.com/fasthttp/websocket"
-	flatbuffers "github.com/google/flatbuffers/go"
 	"image"
 	"image/color"
 	"log"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"gioui.org/app"
 	"gioui.org/io/event"
 	"gioui.org/io/key"
+	"gioui.org/io/system"
 	"gioui.org/op"
 )
 
-var connections = make(map[string]*generated.Player)
+var (
+	worldMut sync.Mutex
+	world    = make(map[string]entities.Entity)
+
+	// playerID - сущность, которой управляет этот клиент
+	playerID string
+	// predicted - позиция своего игрока с учетом еще не подтвержденных вводов
+	predicted     entities.Entity
+	hasPrediction bool
+	pendingInputs []pendingInput
+)
+
+// pendingInput - отправленный ввод, который сервер еще не подтвердил
+type pendingInput struct {
+	seq uint32
+	key string
+}
+
+const (
+	// interpolationDelay - насколько отрисовка чужих сущностей отстает от
+	// последних данных сервера, чтобы всегда было между чем интерполировать
+	interpolationDelay = core.InterpolationDelay
+	// maxExtrapolation - сколько можно продолжать движение по последней
+	// скорости, если следующий пакет опаздывает
+	maxExtrapolation = 100 * time.Millisecond
+	// historySize - сколько последних состояний хранится на сущность
+	historySize = 32
+)
+
+// sample - позиция сущности на тике сервера и время его получения
+type sample struct {
+	tick uint64
+	at   time.Time
+	pos  entities.Position
+}
+
+// history - буфер полученных состояний по сущностям, под worldMut
+var history = make(map[string][]sample)
+
+// roomID - комната, к которой подключается клиент; пустая - комната по умолчанию
+var roomID = flag.String("room", "", "room to join")
+
+// authToken - bearer токен для серверов с включенной аутентификацией
+var authToken = flag.String("token", "", "bearer token for the server")
+
+// clientName - как клиент представляется серверу в Hello
+const clientName = "gio-client"
+
+// schemaHash - хэш схем, из которых собран клиент; сервер сверяет его
+// со своим
+var schemaHash string
+
+// errUpgradeRequired - сервер не принимает эту версию клиента,
+// переподключаться бессмысленно
+var errUpgradeRequired = errors.New("server requires a different client version")
+
+// errRoomUnavailable - комнаты -room на сервере нет (сервер сам их не
+// создает) или она отведена под матч других игроков
+var errRoomUnavailable = errors.New("room not found or reserved for other players")
 
 func main() {
+	flag.Parse()
+
+	var err error
+	if schemaHash, err = schema.Hash(schemes.Files); err != nil {
+		log.Fatal(err)
+	}
+
 	go func() {
 		w := new(app.Window)
 		if err := run(w); err != nil {
@@ -31,24 +107,92 @@ func main() {
 	app.Main()
 }
 
-func drawRedRect(ops *op.Ops) {
-	rect := clip.Rect{Max: image.Pt(100, 100)}
+func drawRedRect(ops *op.Ops, width, height int) {
+	rect := clip.Rect{Max: image.Pt(width, height)}
 	defer rect.Push(ops).Pop()
 
 	paint.ColorOp{Color: color.NRGBA{R: 0x80, A: 0xFF}}.Add(ops)
 	paint.PaintOp{}.Add(ops)
 }
 
-func roomConnector(keyNamePressed <-chan string) {
-	c, _, err := websocket.DefaultDialer.Dial("ws://localhost:8080/game", nil)
+const reconnectDelay = time.Second
+
+// serverTimeout - сколько можно не получать от сервера ничего, даже ping,
+// прежде чем переподключиться
+const serverTimeout = 20 * time.Second
+
+// aimX, aimY - направление прицела, совпадает с последним направлением
+// движения
+var aimX, aimY float32 = 1, 0
+
+// inputSeq - номер последнего отправленного ввода. Не сбрасывается при
+// переподключении, чтобы подтверждения сервера оставались однозначными.
+var inputSeq uint32
+
+// sessionToken выдается сервером в Welcome и позволяет вернуться к своему
+// игроку после обрыва связи
+var sessionToken string
+
+// keyBufferSize - сколько нажатий ждут отправки; остальные отбрасываются,
+// чтобы окно не зависало, пока нет соединения
+const keyBufferSize = 16
+
+// roomConnector переподключается к серверу, пока не получит ошибку, после
+// которой переподключаться бессмысленно, и возвращает ее
+func roomConnector(keyNamePressed chan string) error {
+	for {
+		// нажатия, накопленные без соединения, уже неактуальны
+		for len(keyNamePressed) > 0 {
+			<-keyNamePressed
+		}
+
+		if err := roomSession(keyNamePressed); err != nil {
+			log.Println("connection:", err)
+			if errors.Is(err, errUpgradeRequired) || errors.Is(err, errRoomUnavailable) {
+				return err
+			}
+		}
+		time.Sleep(reconnectDelay)
+	}
+}
+
+func roomSession(keyNamePressed <-chan string) error {
+	header := http.Header{}
+	if sessionToken != "" {
+		header.Set("X-Session-Token", sessionToken)
+	}
+	if *authToken != "" {
+		header.Set("Authorization", "Bearer "+*authToken)
+	}
+
+	address := "ws://localhost:8080/game"
+	if *roomID != "" {
+		address += "?room=" + url.QueryEscape(*roomID)
+	}
+
+	c, resp, err := websocket.DefaultDialer.Dial(address, header)
 	if err != nil {
-		log.Fatal(err)
-		panic(err.Error())
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden) {
+			return errRoomUnavailable
+		}
+		return err
 	}
 
 	defer c.Close()
 
+	c.SetReadDeadline(time.Now().Add(serverTimeout))
+	c.SetPingHandler(func(payload string) error {
+		c.SetReadDeadline(time.Now().Add(serverTimeout))
+		return c.WriteControl(websocket.PongMessage, []byte(payload), time.Now().Add(time.Second))
+	})
+
+	if err := send(c, protocol.BuildHello(protocol.Version, schemaHash, clientName)); err != nil {
+		return err
+	}
+
 	done := make(chan struct{})
+	// rejected - сервер отказал в рукопожатии; пишется до закрытия done
+	rejected := false
 
 	go func() {
 		defer close(done)
@@ -58,52 +202,335 @@ func roomConnector(keyNamePressed <-chan string) {
 				log.Println("read:", err)
 				return
 			}
+			c.SetReadDeadline(time.Now().Add(serverTimeout))
+
+			if len(message) < 8 || !generated.MessageBufferHasIdentifier(message) {
+				log.Println("not a message, skipping")
+				continue
+			}
 
-			playerData := generated.GetRootAsPlayer(message, 0)
-			if playerData == nil {
+			envelope := generated.GetRootAsMessage(message, 0)
+			if envelope.Version() != protocol.Version {
+				log.Println("unsupported protocol version", envelope.Version())
 				continue
 			}
 
-			playerId := string(playerData.Id())
-			connections[playerId] = playerData
+			switch envelope.PayloadType() {
+			case generated.PayloadWelcome:
+				welcome := protocol.PayloadAs[generated.Welcome](envelope)
+				sessionToken = string(welcome.SessionToken())
+
+				worldMut.Lock()
+				playerID = string(welcome.PlayerId())
+				worldMut.Unlock()
+				log.Printf("connected as player %s to room %s (protocol version %d, server schemas %.12s)",
+					welcome.PlayerId(), welcome.Room(), welcome.Version(), welcome.SchemaHash())
+			case generated.PayloadWorldSnapshot:
+				applySnapshot(protocol.PayloadAs[generated.WorldSnapshot](envelope))
+			case generated.PayloadWorldDelta:
+				applyDelta(protocol.PayloadAs[generated.WorldDelta](envelope))
+			case generated.PayloadAck:
+				worldMut.Lock()
+				reconcile(protocol.PayloadAs[generated.Ack](envelope).Seq())
+				worldMut.Unlock()
+			case generated.PayloadGameEvent:
+				event := protocol.PayloadAs[generated.GameEvent](envelope)
+				log.Printf("event %s: %s %s", event.Kind(), event.Entity(), event.Data())
+			case generated.PayloadChat:
+				chat := protocol.PayloadAs[generated.Chat](envelope)
+				log.Printf("chat %s: %s", chat.From(), chat.Text())
+			case generated.PayloadPing:
+				if ping := protocol.PayloadAs[generated.Ping](envelope); !ping.Reply() {
+					send(c, protocol.BuildPing(ping.Id(), ping.Timestamp(), true))
+				}
+			case generated.PayloadShutdown:
+				log.Printf("server is going down: %s", protocol.PayloadAs[generated.Shutdown](envelope).Reason())
+			case generated.PayloadError:
+				serverError := protocol.PayloadAs[generated.Error](envelope)
+				log.Printf("server rejected input %d: %s (%s)", serverError.Seq(), serverError.Code(), serverError.Message())
+				switch serverError.Code() {
+				case generated.ErrorCodeUpgradeRequired, generated.ErrorCodeUnsupportedVersion:
+					rejected = true
+				default:
+					if serverError.Seq() != 0 {
+						worldMut.Lock()
+						dropInput(serverError.Seq())
+						worldMut.Unlock()
+					}
+				}
+			default:
+				log.Println("unexpected message", envelope.PayloadType())
+			}
 		}
 	}()
 
 	for {
 		select {
 		case <-done:
-			return
+			if rejected {
+				return errUpgradeRequired
+			}
+			return nil
 		case keyN := <-keyNamePressed:
-			builder := flatbuffers.NewBuilder(1024)
-			buildKeyToStr := builder.CreateString(keyN)
-			buildActionToStr := builder.CreateString("player_gun")
+			inputSeq++
+			actionName := "player_gun"
+			if core.IsMoveKey(keyN) {
+				actionName = core.MoveActionName
+				predictInput(pendingInput{seq: inputSeq, key: keyN})
 
-			generated.ClientActionStart(builder)
-			generated.ClientActionAddAction(builder, buildActionToStr)
-			generated.ClientActionAddKey(builder, buildKeyToStr)
-			clientAction := generated.ClientActionEnd(builder)
+				dx, dy := core.MoveDelta(keyN)
+				aimX, aimY = float32(dx), float32(dy)
+			}
 
-			builder.Finish(clientAction)
-			finalBuild := builder.FinishedBytes()
+			worldMut.Lock()
+			shownTick := viewTick(time.Now().Add(-interpolationDelay))
+			worldMut.Unlock()
 
-			err := c.WriteMessage(websocket.TextMessage, finalBuild)
+			err := send(c, protocol.BuildInput(protocol.Input{
+				Action:   actionName,
+				Key:      keyN,
+				Seq:      inputSeq,
+				ViewTick: shownTick,
+				AimX:     aimX,
+				AimY:     aimY,
+			}))
 			if err != nil {
-				log.Println("write:", err)
-				return
+				return err
+			}
+		}
+	}
+}
+
+// writeMut - запись в соединение идет и из цикла ввода, и из читателя
+// (ответы на Ping)
+var writeMut sync.Mutex
+
+func send(c *websocket.Conn, data []byte) error {
+	writeMut.Lock()
+	defer writeMut.Unlock()
+
+	return c.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func entityFromTable(entityData *generated.Entity) entities.Entity {
+	return entities.Entity{
+		Name:        string(entityData.Name()),
+		Image:       string(entityData.Image()),
+		IsCollision: entityData.IsCollision(),
+		Owner:       string(entityData.Owner()),
+		Position:    entities.Position{X: int(entityData.X()), Y: int(entityData.Y())},
+		Size:        entities.Size{Width: int(entityData.Width()), Height: int(entityData.Height())},
+	}
+}
+
+// applySnapshot заменяет локальный мир полным состоянием с сервера
+func applySnapshot(snapshot *generated.WorldSnapshot) {
+	worldMut.Lock()
+	defer worldMut.Unlock()
+
+	clear(world)
+	clear(history)
+	entityData := new(generated.Entity)
+	for i := 0; i < snapshot.EntitiesLength(); i++ {
+		if snapshot.Entities(entityData, i) {
+			world[string(entityData.Name())] = entityFromTable(entityData)
+		}
+	}
+
+	recordSamples(snapshot.Tick(), time.Now())
+	reconcile(snapshot.AckSeq())
+}
+
+// applyDelta применяет изменения за тик поверх последнего снапшота
+func applyDelta(worldDelta *generated.WorldDelta) {
+	worldMut.Lock()
+	defer worldMut.Unlock()
+
+	entityData := new(generated.Entity)
+	for i := 0; i < worldDelta.EntitiesLength(); i++ {
+		if worldDelta.Entities(entityData, i) {
+			world[string(entityData.Name())] = entityFromTable(entityData)
+		}
+	}
+
+	delta := new(generated.EntityDelta)
+	for i := 0; i < worldDelta.DeltasLength(); i++ {
+		if worldDelta.Deltas(delta, i) {
+			name := string(delta.Name())
+			world[name] = protocol.Apply(world[name], delta)
+		}
+	}
+
+	for i := 0; i < worldDelta.RemovedLength(); i++ {
+		delete(world, string(worldDelta.Removed(i)))
+	}
+	for i := 0; i < worldDelta.LeftLength(); i++ {
+		delete(world, string(worldDelta.Left(i)))
+	}
+
+	recordSamples(worldDelta.Tick(), time.Now())
+	reconcile(worldDelta.AckSeq())
+}
+
+// recordSamples добавляет в буфер состояние всех известных сущностей на
+// тике tick. Неизменившиеся сущности тоже записываются: две одинаковые
+// позиции на соседних тиках останавливают экстраполяцию. Когда все
+// останавливаются, сервер присылает на следующем тике пустую дельту,
+// так что остановка видна, даже если больше ничего не меняется.
+// Вызывается под worldMut.
+func recordSamples(tick uint64, at time.Time) {
+	for name := range history {
+		if _, ok := world[name]; !ok {
+			delete(history, name)
+		}
+	}
+
+	for name, entity := range world {
+		samples := append(history[name], sample{tick: tick, at: at, pos: entity.Position})
+		if len(samples) > historySize {
+			samples = samples[len(samples)-historySize:]
+		}
+		history[name] = samples
+	}
+}
+
+// viewTick возвращает тик сервера, состояние которого отрисовывается в
+// момент renderAt. Сервер откатывает мир к нему при проверке попаданий.
+// Вызывается под worldMut.
+func viewTick(renderAt time.Time) uint64 {
+	samples := history[playerID]
+	for i := len(samples) - 1; i >= 0; i-- {
+		if !samples[i].at.After(renderAt) {
+			return samples[i].tick
+		}
+	}
+	if len(samples) > 0 {
+		return samples[0].tick
+	}
+	return 0
+}
+
+func lerp(from, to entities.Position, t float64) entities.Position {
+	return entities.Position{
+		X: from.X + int(math.Round(float64(to.X-from.X)*t)),
+		Y: from.Y + int(math.Round(float64(to.Y-from.Y)*t)),
+	}
+}
+
+// interpolatedPosition возвращает позицию сущности на момент renderAt:
+// между двумя полученными состояниями - интерполяцию, после последнего -
+// недолгую экстраполяцию по последней скорости. Вызывается под worldMut.
+func interpolatedPosition(name string, renderAt time.Time) entities.Position {
+	samples := history[name]
+	if len(samples) == 0 {
+		return world[name].Position
+	}
+
+	if !renderAt.After(samples[0].at) {
+		return samples[0].pos
+	}
+
+	last := samples[len(samples)-1]
+	if renderAt.Before(last.at) {
+		for i := len(samples) - 1; i > 0; i-- {
+			from, to := samples[i-1], samples[i]
+			if renderAt.Before(from.at) {
+				continue
 			}
+
+			span := to.at.Sub(from.at)
+			if span <= 0 {
+				return to.pos
+			}
+			return lerp(from.pos, to.pos, float64(renderAt.Sub(from.at))/float64(span))
 		}
+		return last.pos
+	}
+
+	// экстраполируем только непрерывное движение: состояния с соседних тиков
+	if len(samples) < 2 {
+		return last.pos
+	}
+	prev := samples[len(samples)-2]
+	late := renderAt.Sub(last.at)
+	span := last.at.Sub(prev.at)
+	if last.tick != prev.tick+1 || span <= 0 || late > maxExtrapolation {
+		return last.pos
+	}
+
+	return lerp(prev.pos, last.pos, 1+float64(late)/float64(span))
+}
+
+// predictInput сразу применяет ввод к своему игроку, не дожидаясь сервера
+func predictInput(input pendingInput) {
+	worldMut.Lock()
+	defer worldMut.Unlock()
+
+	pendingInputs = append(pendingInputs, input)
+	if hasPrediction {
+		dx, dy := core.MoveDelta(input.key)
+		predicted.X += dx
+		predicted.Y += dy
 	}
 }
 
-func moveReact(ops *op.Ops, player *generated.Player) {
-	pX, pY := int(player.X()), int(player.Y())
-	defer op.Offset(image.Pt(pX, pY)).Push(ops).Pop()
-	drawRedRect(ops)
+// reconcile берет позицию своего игрока из авторитетного состояния сервера,
+// отбрасывает подтвержденные вводы и повторяет поверх нее остальные.
+// Вызывается под worldMut.
+func reconcile(ackSeq uint32) {
+	confirmed := 0
+	for confirmed < len(pendingInputs) && pendingInputs[confirmed].seq <= ackSeq {
+		confirmed++
+	}
+	pendingInputs = pendingInputs[confirmed:]
+	replayInputs()
+}
+
+// dropInput убирает из неподтвержденных ввод seq, который сервер отклонил
+// (например, по лимиту частоты): он никогда не будет подтвержден и не
+// должен повторяться поверх авторитетной позиции. Вызывается под worldMut.
+func dropInput(seq uint32) {
+	for i, input := range pendingInputs {
+		if input.seq == seq {
+			pendingInputs = append(pendingInputs[:i], pendingInputs[i+1:]...)
+			replayInputs()
+			return
+		}
+	}
+}
+
+// replayInputs строит предсказанную позицию своего игрока: авторитетное
+// состояние плюс неподтвержденные вводы. Вызывается под worldMut.
+func replayInputs() {
+	authoritative, ok := world[playerID]
+	if !ok {
+		hasPrediction = false
+		return
+	}
+
+	predicted = authoritative
+	hasPrediction = true
+	for _, input := range pendingInputs {
+		dx, dy := core.MoveDelta(input.key)
+		predicted.X += dx
+		predicted.Y += dy
+	}
+}
+
+func moveReact(ops *op.Ops, entity entities.Entity) {
+	defer op.Offset(image.Pt(entity.X, entity.Y)).Push(ops).Pop()
+	drawRedRect(ops, entity.Width, entity.Height)
 }
 
 func run(w *app.Window) error {
-	var keyNamePressed = make(chan string)
-	go roomConnector(keyNamePressed)
+	var keyNamePressed = make(chan string, keyBufferSize)
+	// ошибка, с которой нельзя продолжать, закрывает окно и завершает
+	// клиент с ней
+	fatal := make(chan error, 1)
+	go func() {
+		fatal <- roomConnector(keyNamePressed)
+		w.Perform(system.ActionClose)
+	}()
 
 	ticker := time.NewTicker(20 * time.Millisecond)
 	defer ticker.Stop()
@@ -120,7 +547,12 @@ func run(w *app.Window) error {
 	for {
 		switch e := w.Event().(type) {
 		case app.DestroyEvent:
-			return e.Err
+			select {
+			case err := <-fatal:
+				return err
+			default:
+				return e.Err
+			}
 
 		case app.FrameEvent:
 			ops.Reset()
@@ -137,13 +569,29 @@ func run(w *app.Window) error {
 				}
 
 				if x, ok := ev.(key.Event); ok && x.State == key.Press {
-					keyNamePressed <- string(x.Name)
+					select {
+					case keyNamePressed <- string(x.Name):
+					default:
+					}
 				}
 			}
 
-			for _, player := range connections {
-				moveReact(&ops, player)
+			// свой игрок рисуется по предсказанию, чужие - с задержкой
+			// interpolationDelay между полученными состояниями
+			renderAt := time.Now().Add(-interpolationDelay)
+
+			worldMut.Lock()
+			for name, entity := range world {
+				if name == playerID {
+					if hasPrediction {
+						entity = predicted
+					}
+				} else {
+					entity.Position = interpolatedPosition(name, renderAt)
+				}
+				moveReact(&ops, entity)
 			}
+			worldMut.Unlock()
 
 			e.Frame(gtx.Ops)
 		}
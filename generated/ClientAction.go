@@ -57,8 +57,56 @@ func (rcv *ClientAction) Key() []byte {
 	return nil
 }
 
+func (rcv *ClientAction) Seq() uint32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(8))
+	if o != 0 {
+		return rcv._tab.GetUint32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *ClientAction) MutateSeq(n uint32) bool {
+	return rcv._tab.MutateUint32Slot(8, n)
+}
+
+func (rcv *ClientAction) ViewTick() uint64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(10))
+	if o != 0 {
+		return rcv._tab.GetUint64(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *ClientAction) MutateViewTick(n uint64) bool {
+	return rcv._tab.MutateUint64Slot(10, n)
+}
+
+func (rcv *ClientAction) AimX() float32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(12))
+	if o != 0 {
+		return rcv._tab.GetFloat32(o + rcv._tab.Pos)
+	}
+	return 0.0
+}
+
+func (rcv *ClientAction) MutateAimX(n float32) bool {
+	return rcv._tab.MutateFloat32Slot(12, n)
+}
+
+func (rcv *ClientAction) AimY() float32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		return rcv._tab.GetFloat32(o + rcv._tab.Pos)
+	}
+	return 0.0
+}
+
+func (rcv *ClientAction) MutateAimY(n float32) bool {
+	return rcv._tab.MutateFloat32Slot(14, n)
+}
+
 func ClientActionStart(builder *flatbuffers.Builder) {
-	builder.StartObject(2)
+	builder.StartObject(6)
 }
 func ClientActionAddAction(builder *flatbuffers.Builder, action flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(action), 0)
@@ -66,6 +114,18 @@ func ClientActionAddAction(builder *flatbuffers.Builder, action flatbuffers.UOff
 func ClientActionAddKey(builder *flatbuffers.Builder, key flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(1, flatbuffers.UOffsetT(key), 0)
 }
+func ClientActionAddSeq(builder *flatbuffers.Builder, seq uint32) {
+	builder.PrependUint32Slot(2, seq, 0)
+}
+func ClientActionAddViewTick(builder *flatbuffers.Builder, viewTick uint64) {
+	builder.PrependUint64Slot(3, viewTick, 0)
+}
+func ClientActionAddAimX(builder *flatbuffers.Builder, aimX float32) {
+	builder.PrependFloat32Slot(4, aimX, 0.0)
+}
+func ClientActionAddAimY(builder *flatbuffers.Builder, aimY float32) {
+	builder.PrependFloat32Slot(5, aimY, 0.0)
+}
 func ClientActionEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
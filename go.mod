@@ -4,16 +4,16 @@ go 1.24.4
 
 require (
 	gioui.org v0.8.0
+	github.com/fasthttp/websocket v1.5.12
+	github.com/google/flatbuffers v25.2.10+incompatible
+	github.com/google/uuid v1.6.0
 	github.com/valyala/fasthttp v1.64.0
 )
 
 require (
 	gioui.org/shader v1.0.8 // indirect
 	github.com/andybalholm/brotli v1.2.0 // indirect
-	github.com/fasthttp/websocket v1.5.12 // indirect
 	github.com/go-text/typesetting v0.2.1 // indirect
-	github.com/google/flatbuffers v25.2.10+incompatible // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/klauspost/compress v1.18.0 // indirect
 	github.com/savsgio/gotils v0.0.0-20240704082632-aef3928b8a38 // indirect
@@ -1,80 +1,581 @@
 package main
 
 import (
-	"encoding/hex"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"hash/fnv"
 	"log"
+	"os"
+	"os/signal"
 	"plugin"
 	"reflect"
-	"sync"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 	"game_web_server/generated"
+	"game_web_server/pkg/auth"
 	"game_web_server/pkg/core"
+	"game_web_server/pkg/entities"
+	"game_web_server/pkg/matchmaking"
+	"game_web_server/pkg/network"
+	"game_web_server/pkg/protocol"
+	"game_web_server/pkg/room"
 	"game_web_server/pkg/scripts"
 	"game_web_server/pkg/schema"
+	"game_web_server/pkg/session"
+	"game_web_server/schemes"
 	"github.com/fasthttp/websocket"
 	"github.com/valyala/fasthttp"
 )
 
 type GameHandler struct {
-	mut         sync.Mutex
-	connections map[string]*websocket.Conn
-	engine      *core.Engine
+	// verifier проверяет токен до апгрейда соединения; nil - без аутентификации
+	verifier    auth.Verifier
+	origins     *network.OriginPolicy
+	ipLimiter   *network.IPLimiter
+	rateLimits  network.RateLimits
+	heartbeat   network.Heartbeat
+	sessions    *session.Manager
+	rooms       *room.Manager
+	matchmaking *matchmaking.Service
+	// schemaHash - хэш схем из schemes/, с которым сверяются клиенты
+	schemaHash string
+	// closing - сервер останавливается и больше не принимает игроков
+	closing atomic.Bool
 }
 
+// readLimit - жесткий предел размера входящего кадра. Сообщения больше
+// protocol.MaxClientMessageSize, но в пределах readLimit, отклоняются с
+// ошибкой TooLarge; больше readLimit - соединение закрывается.
+const readLimit = 16 * protocol.MaxClientMessageSize
+
+// handshakeTimeout - сколько ждать Hello после апгрейда соединения
+const handshakeTimeout = 5 * time.Second
+
+// roomJoinTimeout - сколько комната, созданная заранее (под матч или
+// через POST /rooms), ждет игроков, прежде чем будет удалена
+const roomJoinTimeout = time.Minute
+
 func (h *GameHandler) pingPongHandler(ctx *fasthttp.RequestCtx) {
 	fmt.Fprint(ctx, "pong")
 }
 
-func hash(str string) string {
-	fmt.Printf("Hashing string: '%s' (length: %d)\n", str, len(str))
-	fmt.Printf("Bytes: %v\n", []byte(str))
+// sessionToken достает токен сессии из query параметра или заголовка
+func sessionToken(ctx *fasthttp.RequestCtx) string {
+	if token := ctx.QueryArgs().Peek("session"); len(token) > 0 {
+		return string(token)
+	}
+	return string(ctx.Request.Header.Peek("X-Session-Token"))
+}
+
+// authToken достает bearer токен из заголовка Authorization или query
+// параметра token (браузерный WebSocket не умеет задавать заголовки)
+func authToken(ctx *fasthttp.RequestCtx) string {
+	header := string(ctx.Request.Header.Peek("Authorization"))
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return string(ctx.QueryArgs().Peek("token"))
+}
+
+// authenticate возвращает проверенного пользователя. Без verifier все
+// подключения анонимные.
+func (h *GameHandler) authenticate(ctx *fasthttp.RequestCtx) (auth.Identity, error) {
+	if h.verifier == nil {
+		return auth.Identity{}, nil
+	}
+	return h.verifier.Verify(authToken(ctx))
+}
+
+// authorized проверяет токен запроса и при неудаче отвечает 401
+func (h *GameHandler) authorized(ctx *fasthttp.RequestCtx) bool {
+	if _, err := h.authenticate(ctx); err != nil {
+		log.Printf("Authentication failed for %s: %v", ctx.RemoteAddr(), err)
+		ctx.Response.Header.Set("WWW-Authenticate", "Bearer")
+		ctx.Error("unauthorized", fasthttp.StatusUnauthorized)
+		return false
+	}
+	return true
+}
 
-	newHash := fnv.New32a()
-	_, err := newHash.Write([]byte(str))
+func writeJSON(ctx *fasthttp.RequestCtx, status int, value any) {
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(status)
+	if err := json.NewEncoder(ctx).Encode(value); err != nil {
+		log.Printf("JSON encode failed: %v", err)
+	}
+}
+
+// roomsHandler: GET возвращает список комнат, POST ?room=id создает
+// комнату (без id - со случайным). Создание требует того же токена, что
+// и /game; комната, в которую никто не зашел, удаляется.
+func (h *GameHandler) roomsHandler(ctx *fasthttp.RequestCtx) {
+	switch {
+	case ctx.IsGet():
+		writeJSON(ctx, fasthttp.StatusOK, h.rooms.List())
+	case ctx.IsPost():
+		if !h.authorized(ctx) {
+			return
+		}
+		created, err := h.rooms.Create(string(ctx.QueryArgs().Peek("room")))
+		switch {
+		case errors.Is(err, room.ErrRoomExists):
+			ctx.Error(err.Error(), fasthttp.StatusConflict)
+			return
+		case errors.Is(err, room.ErrBadRoomID):
+			ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+			return
+		case errors.Is(err, room.ErrTooManyRooms):
+			ctx.Error(err.Error(), fasthttp.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+			return
+		}
+		time.AfterFunc(roomJoinTimeout, func() {
+			h.rooms.RemoveIfEmpty(created.ID)
+		})
+		writeJSON(ctx, fasthttp.StatusCreated, created.Info())
+	default:
+		ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+	}
+}
+
+// lobbyHandler отдает JSON с присутствием игроков для дашборда и
+// лаунчера, которым не нужен WebSocket:
+//
+//	GET /lobby/players       - игроки онлайн
+//	GET /lobby/players/<id>  - игрок и его текущая сущность
+//	GET /lobby/worlds        - сколько игроков онлайн в каждой комнате
+//
+// Если включена авторизация, нужен тот же токен, что и для /game.
+func (h *GameHandler) lobbyHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsGet() {
+		ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(ctx) {
+		return
+	}
+
+	path := string(ctx.Path())
+	switch {
+	case path == "/lobby/players":
+		writeJSON(ctx, fasthttp.StatusOK, h.rooms.Online())
+	case strings.HasPrefix(path, "/lobby/players/"):
+		presence, ok := h.rooms.Presence(strings.TrimPrefix(path, "/lobby/players/"))
+		if !ok {
+			ctx.Error("player not found", fasthttp.StatusNotFound)
+			return
+		}
+		writeJSON(ctx, fasthttp.StatusOK, presence)
+	case path == "/lobby/worlds":
+		worlds := h.rooms.List()
+		total := 0
+		for _, world := range worlds {
+			total += world.Online
+		}
+		writeJSON(ctx, fasthttp.StatusOK, map[string]any{
+			"online": total,
+			"worlds": worlds,
+		})
+	default:
+		ctx.Error("not found", fasthttp.StatusNotFound)
+	}
+}
+
+// startMatch создает для собранного матча новую комнату со своим движком
+func (h *GameHandler) startMatch(match matchmaking.Match) (string, error) {
+	players := make([]string, 0, len(match.Players))
+	for _, ticket := range match.Players {
+		players = append(players, ticket.PlayerID)
+	}
+
+	// в комнату матча пускаются только собранные в него игроки
+	created, err := h.rooms.Create("match-"+match.ID, players...)
 	if err != nil {
-		fmt.Printf("Error writing to hash: %v\n", err)
-		return ""
+		return "", err
+	}
+
+	log.Printf("Match %s (%s) started in room %s with %d players", match.ID, match.Mode, created.ID, len(match.Players))
+	time.AfterFunc(roomJoinTimeout, func() {
+		h.rooms.RemoveIfEmpty(created.ID)
+	})
+	return created.ID, nil
+}
+
+// matchmakingHandler обслуживает очередь подбора. Игрок определяется по
+// токену сессии, полученному при подключении к /game. Собранный матч
+// виден в статусе: клиент подключается к /game?room=<room>.
+func (h *GameHandler) matchmakingHandler(ctx *fasthttp.RequestCtx) {
+	playerSession, ok := h.sessions.Get(sessionToken(ctx))
+	if !ok {
+		ctx.Error("unknown session", fasthttp.StatusUnauthorized)
+		return
+	}
+	playerID := playerSession.PlayerID
+
+	switch string(ctx.Path()) {
+	case "/matchmaking/enqueue":
+		if !ctx.IsPost() {
+			ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+			return
+		}
+
+		rating, err := ctx.QueryArgs().GetUint("rating")
+		if err != nil {
+			ctx.Error("rating must be a non-negative integer", fasthttp.StatusBadRequest)
+			return
+		}
+
+		_, err = h.matchmaking.Enqueue(playerID, rating, string(ctx.QueryArgs().Peek("mode")))
+		switch {
+		case errors.Is(err, matchmaking.ErrUnknownMode):
+			ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+			return
+		case errors.Is(err, matchmaking.ErrAlreadyQueued):
+			ctx.Error(err.Error(), fasthttp.StatusConflict)
+			return
+		}
+
+		status, _ := h.matchmaking.Status(playerID)
+		writeJSON(ctx, fasthttp.StatusOK, status)
+	case "/matchmaking/status":
+		status, ok := h.matchmaking.Status(playerID)
+		if !ok {
+			ctx.Error("not queued", fasthttp.StatusNotFound)
+			return
+		}
+		writeJSON(ctx, fasthttp.StatusOK, status)
+	case "/matchmaking/cancel":
+		if !ctx.IsPost() {
+			ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+			return
+		}
+		if !h.matchmaking.Cancel(playerID) {
+			ctx.Error("not queued", fasthttp.StatusNotFound)
+			return
+		}
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	default:
+		ctx.Error("not found", fasthttp.StatusNotFound)
+	}
+}
+
+// leaveHandler обслуживает POST /rooms/leave?session=<token>: игрок
+// выходит из комнаты, его соединение закрывается, а сессия завершается
+// сразу, без grace period.
+func (h *GameHandler) leaveHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsPost() {
+		ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+		return
+	}
+
+	token := sessionToken(ctx)
+	playerSession, ok := h.sessions.Get(token)
+	if !ok {
+		ctx.Error("unknown session", fasthttp.StatusUnauthorized)
+		return
+	}
+
+	// соединение закрывается заранее: выход из комнаты оборвал бы его без
+	// close фрейма
+	if current, ok := h.rooms.RoomOf(playerSession.PlayerID); ok {
+		if client, ok := current.Connection(playerSession.PlayerID); ok {
+			client.CloseGracefully(websocket.CloseNormalClosure, "left the room")
+			select {
+			case <-client.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+
+	// callback истечения сессии снимают игрока с матчмейкинга и выводят
+	// из комнаты
+	if _, ok := h.sessions.End(token); !ok {
+		ctx.Error("unknown session", fasthttp.StatusUnauthorized)
+		return
+	}
+	log.Printf("Player %s left", playerSession.PlayerID)
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// errorReply сериализует err в Error с кодом из ValidationError
+func errorReply(err error, seq uint32) []byte {
+	code := generated.ErrorCodeUnknown
+	var validationErr *protocol.ValidationError
+	if errors.As(err, &validationErr) {
+		code = validationErr.Code
+	}
+	return protocol.BuildError(code, err.Error(), seq)
+}
+
+// reject сообщает клиенту, почему его сообщение отклонено
+func reject(client *network.Client, err error, seq uint32) {
+	log.Printf("Rejected message from player %s: %v", client.ID, err)
+	client.Send(errorReply(err, seq))
+}
+
+// closeWithError отправляет клиенту err (seq - номер отклоненного ввода),
+// закрывает соединение с code и reason и ждет, пока ошибка уйдет
+func closeWithError(client *network.Client, err error, seq uint32, code int, reason string) {
+	client.CloseWith(errorReply(err, seq), code, reason)
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+	}
+}
+
+// handshake ждет от клиента Hello первым сообщением не дольше
+// handshakeTimeout и проверяет версию протокола и хэш схем. Клиенту,
+// который прислал что-то другое или несовместим, отправляется ошибка, и
+// соединение закрывается; тогда возвращает false.
+func (h *GameHandler) handshake(conn *websocket.Conn, client *network.Client) bool {
+	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	data, err := client.Read()
+	if err != nil {
+		log.Printf("Connection from %s did not complete the handshake: %v", conn.RemoteAddr(), err)
+		return false
+	}
+
+	message, err := protocol.VerifyClientMessage(data)
+	if err == nil && message.PayloadType() != generated.PayloadHello {
+		err = &protocol.ValidationError{
+			Code:   generated.ErrorCodeUnexpectedMessage,
+			Reason: "expected Hello, got " + message.PayloadType().String(),
+		}
+	}
+	clientName := ""
+	if err == nil {
+		hello := protocol.PayloadAs[generated.Hello](message)
+		clientName = string(hello.Client())
+		err = protocol.CheckHello(hello.Version(), string(hello.SchemaHash()), h.schemaHash)
+	}
+	if err != nil {
+		log.Printf("Connection from %s rejected at handshake (client %q): %v", conn.RemoteAddr(), clientName, err)
+		closeWithError(client, err, 0, websocket.CloseProtocolError, "handshake failed")
+		return false
+	}
+	return true
+}
+
+// handleMessage обрабатывает проверенное сообщение клиента по типу его
+// payload. Возвращает false, если соединение нужно закрыть.
+func (h *GameHandler) handleMessage(client *network.Client, currentRoom *room.Room, limiter *network.ActionLimiter, message *generated.Message) bool {
+	// вводы ограничиваются по имени действия, остальные сообщения - по
+	// типу, каждый в своей корзине
+	limitKey := strings.ToLower(message.PayloadType().String())
+	var clientAction *generated.ClientAction
+	var seq uint32
+	var allowed bool
+	if message.PayloadType() == generated.PayloadClientAction {
+		clientAction = protocol.PayloadAs[generated.ClientAction](message)
+		limitKey = string(clientAction.Action())
+		seq = clientAction.Seq()
+		allowed = limiter.Allow(limitKey, time.Now())
+	} else {
+		allowed = limiter.AllowMessage(limitKey, time.Now())
+	}
+
+	if !allowed {
+		err := &protocol.ValidationError{
+			Code:   generated.ErrorCodeRateLimited,
+			Reason: "too many " + limitKey + " messages",
+		}
+
+		// close уходит через очередь клиента, после ошибки
+		if limiter.Exceeded() {
+			log.Printf("Player %s disconnected after %d rate limit violations", client.ID, limiter.Violations())
+			closeWithError(client, err, seq, websocket.ClosePolicyViolation, "rate limit exceeded")
+			return false
+		}
+		reject(client, err, seq)
+		return true
+	}
+
+	switch message.PayloadType() {
+	case generated.PayloadClientAction:
+		if !currentRoom.Engine.HasAction(limitKey) {
+			reject(client, &protocol.ValidationError{
+				Code:   generated.ErrorCodeUnknownAction,
+				Reason: "unknown action " + strconv.Quote(limitKey),
+			}, seq)
+			return true
+		}
+
+		fmt.Println(">>", string(clientAction.Key()), limitKey)
+		select {
+		case currentRoom.Engine.CActionChan <- &core.Input{
+			PlayerID: client.ID,
+			Action:   clientAction,
+		}:
+		case <-currentRoom.Engine.Done():
+			return false
+		}
+	case generated.PayloadChat:
+		text := strings.TrimSpace(string(protocol.PayloadAs[generated.Chat](message).Text()))
+		if text != "" {
+			currentRoom.Send(protocol.BuildChat(client.ID, text))
+		}
+	case generated.PayloadPing:
+		ping := protocol.PayloadAs[generated.Ping](message)
+		if !ping.Reply() {
+			client.Send(protocol.BuildPing(ping.Id(), ping.Timestamp(), true))
+		}
+	case generated.PayloadAck:
+		// подтверждения тиков от клиента пока не используются
 	}
+	return true
+}
 
-	result := hex.EncodeToString(newHash.Sum(nil))
-	fmt.Printf("Result hash: %s\n", result)
-	return result
+// shutdown перестает принимать игроков, останавливает матчмейкинг и
+// комнаты: клиенты получают reason, плагины - Stop, мир сохраняется в
+// saveDir
+func (h *GameHandler) shutdown(ctx context.Context, reason, saveDir string) error {
+	h.closing.Store(true)
+	h.matchmaking.Stop()
+	return h.rooms.Shutdown(ctx, reason, saveDir)
 }
 
 func (h *GameHandler) serveWebSocket(ctx *fasthttp.RequestCtx) {
 	upgrader := websocket.FastHTTPUpgrader{
 		CheckOrigin: func(ctx *fasthttp.RequestCtx) bool {
-			return true
+			return h.origins.Allows(string(ctx.Request.Header.Peek("Origin")))
 		},
 	}
 
-	err := upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
-		remoteStrAddr := conn.RemoteAddr().String()
-		playerID := hash(remoteStrAddr)
+	ip := ctx.RemoteIP().String()
+	if h.closing.Load() {
+		ctx.Error("server is shutting down", fasthttp.StatusServiceUnavailable)
+		return
+	}
+	if !websocket.FastHTTPIsWebSocketUpgrade(ctx) {
+		ctx.Error("websocket upgrade required", fasthttp.StatusBadRequest)
+		return
+	}
 
-		defer func() {
-			conn.Close()
-			h.connections[playerID] = nil
-		}()
+	if origin := string(ctx.Request.Header.Peek("Origin")); !h.origins.Allows(origin) {
+		log.Printf("Rejected connection from %s: origin %q is not allowed", ip, origin)
+		ctx.Error("origin not allowed", fasthttp.StatusForbidden)
+		return
+	}
 
-		h.connections[playerID] = conn
+	// ранний отказ без занятия слота; слот занимает обработчик соединения
+	if h.ipLimiter.Full(ip) {
+		log.Printf("Rejected connection from %s: too many connections", ip)
+		ctx.Error("too many connections", fasthttp.StatusTooManyRequests)
+		return
+	}
+
+	identity, err := h.authenticate(ctx)
+	if err != nil {
+		log.Printf("Authentication failed for %s: %v", ctx.RemoteAddr(), err)
+		ctx.Response.Header.Set("WWW-Authenticate", "Bearer")
+		ctx.Error("unauthorized", fasthttp.StatusUnauthorized)
+		return
+	}
+
+	// ctx нельзя использовать после апгрейда, поэтому параметры читаются
+	// заранее. Сессия, слот IP и вход в комнату занимаются только внутри
+	// обработчика соединения: если ответ 101 не удалось отправить,
+	// fasthttp его не вызывает, и освобождать будет нечего.
+	token := sessionToken(ctx)
+	roomID := string(ctx.QueryArgs().Peek("room"))
+	if roomID == "" {
+		roomID = room.DefaultRoom
+	}
+	// комнаты создаются только через POST /rooms и матчмейкинг
+	target, ok := h.rooms.Get(roomID)
+	if !ok {
+		ctx.Error("room not found", fasthttp.StatusNotFound)
+		return
+	}
+	if playerSession, _ := h.sessions.Get(token); !target.Admits(playerSession.PlayerID) {
+		log.Printf("Rejected connection from %s: room %s is reserved", ip, roomID)
+		ctx.Error("room is reserved", fasthttp.StatusForbidden)
+		return
+	}
+
+	err = upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+		if !h.ipLimiter.Acquire(ip) {
+			log.Printf("Rejected connection from %s: too many connections", ip)
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too many connections"),
+				time.Now().Add(time.Second))
+			return
+		}
+		defer h.ipLimiter.Release(ip)
+
+		client := network.NewClient("", conn)
+		client.UserID = identity.UserID
+		go client.WritePump()
+		defer client.Close()
+
+		conn.SetReadLimit(readLimit)
+		// игрок появляется в комнате только после успешного рукопожатия
+		if !h.handshake(conn, client) {
+			return
+		}
+
+		playerSession, resumed := h.sessions.Connect(token, identity.UserID)
+		defer h.sessions.Disconnect(playerSession)
+		playerID := playerSession.PlayerID
+		client.ID = playerID
+
+		currentRoom, err := h.rooms.Join(roomID, playerID, identity.UserID)
+		if err != nil {
+			log.Printf("Player %s failed to join room %s: %v", playerID, roomID, err)
+			code := websocket.CloseTryAgainLater
+			if errors.Is(err, room.ErrRoomReserved) {
+				code = websocket.ClosePolicyViolation
+			}
+			closeWithError(client, err, 0, code, "join room failed")
+			return
+		}
+		log.Printf("Player %s (user %q) connected to room %s from %s (resumed: %v)", playerID, identity.UserID, currentRoom.ID, conn.RemoteAddr(), resumed)
+
+		// Welcome должен прийти раньше первой рассылки комнаты
+		client.Send(protocol.BuildWelcome(protocol.Welcome{
+			SchemaHash:   h.schemaHash,
+			SessionToken: playerSession.Token,
+			PlayerID:     playerID,
+			Room:         currentRoom.ID,
+		}))
+		currentRoom.Attach(client)
+		defer currentRoom.Detach(client)
+
+		limiter := network.NewActionLimiter(h.rateLimits)
+		client.KeepAlive(h.heartbeat)
 
 		for {
-			_, message, err := conn.ReadMessage()
+			data, err := client.Read()
 			if err != nil {
 				log.Println("Read error:", err)
 				break
 			}
 
-			clientAction := generated.GetRootAsClientAction(message, 0)
-			if clientAction == nil {
-				log.Println("ClientAction is nil!")
+			message, err := protocol.VerifyClientMessage(data)
+			if err != nil {
+				reject(client, err, 0)
+				continue
+			}
+
+			if message.PayloadType() == generated.PayloadHello {
+				reject(client, &protocol.ValidationError{
+					Code:   generated.ErrorCodeUnexpectedMessage,
+					Reason: "handshake is already done",
+				}, 0)
 				continue
 			}
 
-			fmt.Println(">>", string(clientAction.Key()), string(clientAction.Action()))
-			h.engine.CActionChan <- clientAction
+			if !h.handleMessage(client, currentRoom, limiter, message) {
+				return
+			}
 		}
 	})
 	if err != nil {
@@ -83,39 +584,6 @@ func (h *GameHandler) serveWebSocket(ctx *fasthttp.RequestCtx) {
 	}
 }
 
-//func buildPlayerForBroadcast(player *entities.Player) []byte {
-//	builder := flatbuffers.NewBuilder(1024)
-//
-//	buildPlayerID := builder.CreateString(player.ID)
-//	buildPlayerIP := builder.CreateString(player.IP)
-//
-//	generated.PlayerStart(builder)
-//	generated.PlayerAddId(builder, buildPlayerID)
-//	generated.PlayerAddIp(builder, buildPlayerIP)
-//	generated.PlayerAddX(builder, player.Position.X)
-//	generated.PlayerAddY(builder, player.Position.Y)
-//	buildPlayer := generated.PlayerEnd(builder)
-//
-//	builder.Finish(buildPlayer)
-//	return builder.FinishedBytes()
-//}
-
-//func (h *GameHandler) broadcast(data []byte) {
-//	for _, conn := range h.connections {
-//		go func() {
-//			err := conn.WriteMessage(websocket.BinaryMessage, data)
-//			if err != nil {
-//				log.Println("Write error:", err)
-//			}
-//		}()
-//	}
-//}
-
-//func (h *GameHandler) broadcastPlayer(player *entities.Player) {
-//	buildForSend := buildPlayerForBroadcast(player)
-//	h.broadcast(buildForSend)
-//}
-
 //func (h *GameHandler) RegisterAction(action uint16, key string, handler ActionHandlerType) {
 //	actionName := makeActionName(action, key)
 //
@@ -133,14 +601,30 @@ func (h *GameHandler) HandleFastHTTP(ctx *fasthttp.RequestCtx) {
 		h.pingPongHandler(ctx)
 	case "/game":
 		h.serveWebSocket(ctx)
+	case "/rooms":
+		h.roomsHandler(ctx)
+	case "/rooms/leave":
+		h.leaveHandler(ctx)
 	default:
-		ctx.Error("not found", fasthttp.StatusNotFound)
+		switch path := string(ctx.Path()); {
+		case strings.HasPrefix(path, "/matchmaking/"):
+			h.matchmakingHandler(ctx)
+		case strings.HasPrefix(path, "/lobby/"):
+			h.lobbyHandler(ctx)
+		default:
+			ctx.Error("not found", fasthttp.StatusNotFound)
+		}
 	}
 }
 
 type ClientAction struct {
 	Name string `json:"action"`
 	Key  string `json:"key"`
+	Seq  uint32 `json:"seq"`
+	// ViewTick - тик сервера, который показывал клиент, для компенсации задержки
+	ViewTick uint64  `json:"view_tick"`
+	AimX     float32 `json:"aim_x"`
+	AimY     float32 `json:"aim_y"`
 }
 
 // generateSchemas генерирует FlatBuffer схемы для всех структур в программе
@@ -187,26 +671,123 @@ func compileSchemas() error {
 	return nil
 }
 
-func pluginsRunner(e *core.Engine, files []string) {
+// pluginsRunner загружает плагины в движок комнаты. Start каждого плагина
+// выполняется до запуска движка, так что зарегистрированные им действия
+// доступны с первого тика.
+func pluginsRunner(e *core.Engine, files []string) error {
 	for _, filename := range files {
 		path := "scripts/" + filename
 		fmt.Println("Loading plugin:", path)
 		p, err := plugin.Open(path)
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("open plugin %s: %w", path, err)
 		}
 
 		sym, err := p.Lookup("Start")
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("plugin %s: %w", path, err)
 		}
 
-		initFunc := sym.(func(*core.Engine))
-		go initFunc(e)
+		initFunc, ok := sym.(func(*core.Engine))
+		if !ok {
+			return fmt.Errorf("plugin %s: Start must be func(*core.Engine), got %T", path, sym)
+		}
+		initFunc(e)
+
+		// Stop необязателен: его экспортируют плагины, которым нужно
+		// что-то освободить или сохранить при остановке движка
+		if sym, err := p.Lookup("Stop"); err == nil {
+			stopFunc, ok := sym.(func(*core.Engine))
+			if !ok {
+				return fmt.Errorf("plugin %s: Stop must be func(*core.Engine), got %T", path, sym)
+			}
+			e.OnStop(func() { stopFunc(e) })
+		}
+	}
+	return nil
+}
+
+// newVerifier создает проверку токенов для режима -auth
+func newVerifier(mode, secret, issuer, tokensPath string) (auth.Verifier, error) {
+	switch mode {
+	case "none":
+		return nil, nil
+	case "jwt":
+		if secret == "" {
+			return nil, errors.New("-auth jwt requires -auth-secret or AUTH_JWT_SECRET")
+		}
+		verifier := auth.NewJWTVerifier([]byte(secret))
+		verifier.Issuer = issuer
+		return verifier, nil
+	case "static":
+		return auth.LoadStaticTokens(tokensPath)
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", mode)
 	}
 }
 
 func main() {
+	tickRate := flag.Int("tick-rate", core.DefaultTickRate, "game loop ticks per second")
+	playerTemplate := flag.String("player-template", "entities/player_1.json", "entity file used to spawn connected players")
+	viewWidth := flag.Int("view-width", network.DefaultViewArea.Width, "width of the area around a player that receives updates")
+	viewHeight := flag.Int("view-height", network.DefaultViewArea.Height, "height of the area around a player that receives updates")
+	sessionGrace := flag.Duration("session-grace", session.DefaultGracePeriod, "how long a dropped player can reconnect and keep the session")
+	authMode := flag.String("auth", "none", "token verification on /game: none, jwt or static")
+	authSecret := flag.String("auth-secret", "", "HS256 secret for -auth jwt, $AUTH_JWT_SECRET if empty")
+	authIssuer := flag.String("auth-issuer", "", "required JWT issuer for -auth jwt")
+	authTokens := flag.String("auth-tokens", "tokens.txt", "file with \"<token> <user>\" lines for -auth static")
+	allowedOrigins := flag.String("allowed-origins", "", "comma-separated origins allowed to open /game, empty allows any")
+	maxConnsPerIP := flag.Int("max-conns-per-ip", network.DefaultMaxConnsPerIP, "concurrent /game connections per IP, 0 for no limit")
+	limits := network.DefaultRateLimits()
+	actionRate := flag.Float64("action-rate", limits.Default.PerSecond, "actions per second allowed per connection for actions without their own limit")
+	actionBurst := flag.Int("action-burst", limits.Default.Burst, "burst size for -action-rate")
+	actionLimits := flag.String("action-limits", "player_gun=5:5", "per-action limits as action=per_second:burst, comma-separated")
+	messageLimits := flag.String("message-limits", "chat=1:5,ping=5:10,ack=60:120", "limits for chat, ping and ack messages as type=per_second:burst, comma-separated; each type has its own bucket")
+	maxViolations := flag.Int("max-violations", limits.MaxViolations, "dropped messages after which a connection is closed, 0 to never close")
+	heartbeat := network.DefaultHeartbeat()
+	pingInterval := flag.Duration("ping-interval", heartbeat.PingInterval, "how often WebSocket pings are sent to measure RTT and detect dead peers")
+	pongTimeout := flag.Duration("pong-timeout", heartbeat.PongTimeout, "how long a connection may stay silent, pongs included, before it is dropped")
+	idleTimeout := flag.Duration("idle-timeout", heartbeat.IdleTimeout, "close connections that send no game messages for this long, 0 to keep them")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 10*time.Second, "how long a SIGINT/SIGTERM shutdown may take before the process exits anyway")
+	saveDir := flag.String("save-dir", "saves", "directory where room worlds are saved on shutdown")
+	maxRooms := flag.Int("max-rooms", room.DefaultMaxRooms, "how many rooms may exist at once, the default one included")
+	flag.Parse()
+
+	if *pingInterval <= 0 || *pongTimeout <= *pingInterval {
+		log.Printf("-ping-interval must be positive and shorter than -pong-timeout")
+		return
+	}
+	heartbeat = network.Heartbeat{PingInterval: *pingInterval, PongTimeout: *pongTimeout, IdleTimeout: *idleTimeout}
+
+	if *actionRate <= 0 || *actionBurst <= 0 {
+		log.Printf("-action-rate and -action-burst must be positive")
+		return
+	}
+	rates, err := network.ParseRates(*actionLimits)
+	if err != nil {
+		log.Printf("Bad -action-limits: %v", err)
+		return
+	}
+	messageRates, err := network.ParseRates(*messageLimits)
+	if err != nil {
+		log.Printf("Bad -message-limits: %v", err)
+		return
+	}
+	limits.Default = network.Rate{PerSecond: *actionRate, Burst: *actionBurst}
+	limits.Actions = rates
+	limits.Messages = messageRates
+	limits.MaxViolations = *maxViolations
+
+	if *authSecret == "" {
+		*authSecret = os.Getenv("AUTH_JWT_SECRET")
+	}
+
+	verifier, err := newVerifier(*authMode, *authSecret, *authIssuer, *authTokens)
+	if err != nil {
+		log.Printf("Auth setup failed: %v", err)
+		return
+	}
+
 	if err := generateSchemas(); err != nil {
 		log.Printf("Schema generation failed: %v", err)
 		return
@@ -217,12 +798,20 @@ func main() {
 		return
 	}
 
-	engine := core.NewEngine()
-	engine.Start()
+	schemaHash, err := schema.HashDir("schemes")
+	if err != nil {
+		log.Printf("Schema hash failed: %v", err)
+		return
+	}
+	if builtIn, err := schema.Hash(schemes.Files); err == nil && builtIn != schemaHash {
+		log.Printf("Schemas in schemes/ differ from the ones the server was built with, rebuild the server and clients")
+	}
+	fmt.Println("Schema hash:", schemaHash)
 
-	gameHandler := &GameHandler{
-		connections: make(map[string]*websocket.Conn),
-		engine:      engine,
+	template, err := entities.LoadTemplate(*playerTemplate)
+	if err != nil {
+		log.Printf("Player template load failed: %v", err)
+		return
 	}
 
 	pluginsFiles, err := scripts.BuildPlugins("scripts")
@@ -230,11 +819,81 @@ func main() {
 		panic(err.Error())
 	}
 
-	pluginsRunner(engine, pluginsFiles)
+	// каждая комната получает свой движок с собственным набором сущностей
+	// из entities/ и своими экземплярами плагинов
+	newRoomEngine := func(roomID string) (*core.Engine, error) {
+		engine := core.NewEngine(*tickRate)
+		engine.PlayerTemplate = template
+		if err := pluginsRunner(engine, pluginsFiles); err != nil {
+			engine.Stop()
+			return nil, err
+		}
+		return engine, nil
+	}
+
+	gameHandler := &GameHandler{
+		verifier:   verifier,
+		origins:    network.NewOriginPolicy(strings.Split(*allowedOrigins, ",")),
+		ipLimiter:  network.NewIPLimiter(*maxConnsPerIP),
+		rateLimits: limits,
+		heartbeat:  heartbeat,
+		sessions:   session.NewManager(*sessionGrace),
+		rooms:      room.NewManager(newRoomEngine, entities.Size{Width: *viewWidth, Height: *viewHeight}, *maxRooms),
+		schemaHash: schemaHash,
+	}
+
+	gameHandler.sessions.OnExpire(func(playerID string) {
+		log.Printf("Session of player %s ended", playerID)
+		gameHandler.matchmaking.Cancel(playerID)
+		gameHandler.rooms.Leave(playerID)
+	})
+
+	if _, err := gameHandler.rooms.Create(room.DefaultRoom); err != nil {
+		log.Printf("Default room creation failed: %v", err)
+		return
+	}
+
+	gameHandler.matchmaking = matchmaking.NewService(matchmaking.DefaultConfig(), gameHandler.startMatch)
+	gameHandler.matchmaking.Start()
 
 	fmt.Println("\nStarting web server on :8080...")
 
-	if err := fasthttp.ListenAndServe(":8080", gameHandler.HandleFastHTTP); err != nil {
+	server := &fasthttp.Server{Handler: gameHandler.HandleFastHTTP}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe(":8080")
+	}()
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serveErr:
 		panic(err.Error())
+	case <-signals.Done():
+	}
+	// повторный сигнал завершает процесс сразу
+	stopSignals()
+
+	log.Printf("Shutting down, waiting up to %v", *shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := gameHandler.shutdown(ctx, "server is shutting down", *saveDir); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+		if err := server.ShutdownWithContext(ctx); err != nil {
+			log.Printf("HTTP server shutdown: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+		log.Printf("Server stopped")
+	case <-ctx.Done():
+		log.Printf("Shutdown deadline exceeded, exiting")
 	}
 }
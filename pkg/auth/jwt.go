@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// clockSkew - допустимое расхождение часов при проверке exp и nbf
+const clockSkew = 30 * time.Second
+
+// JWTVerifier проверяет JWT, подписанные HMAC-SHA256 (HS256). Пользователь
+// берется из claim "sub".
+type JWTVerifier struct {
+	secret []byte
+	// Issuer, если задан, должен совпадать с claim "iss"
+	Issuer string
+	now    func() time.Time
+}
+
+// NewJWTVerifier создает проверку JWT с общим секретом
+func NewJWTVerifier(secret []byte) *JWTVerifier {
+	return &JWTVerifier{
+		secret: secret,
+		now:    time.Now,
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+func decodeSegment(segment string, value any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func (v *JWTVerifier) Verify(token string) (Identity, error) {
+	if token == "" {
+		return Identity{}, ErrMissingToken
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, ErrInvalidToken
+	}
+
+	// алгоритм фиксирован: "none" и асимметричные подписи не принимаются
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, err
+	}
+	if header.Alg != "HS256" {
+		return Identity{}, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return Identity{}, ErrInvalidToken
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, err
+	}
+
+	now := v.now()
+	if claims.ExpiresAt != nil && now.After(time.Unix(*claims.ExpiresAt, 0).Add(clockSkew)) {
+		return Identity{}, ErrExpiredToken
+	}
+	if claims.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(*claims.NotBefore, 0)) {
+		return Identity{}, ErrInvalidToken
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		return Identity{}, ErrInvalidToken
+	}
+	if claims.Subject == "" {
+		return Identity{}, ErrInvalidToken
+	}
+
+	return Identity{UserID: claims.Subject}, nil
+}
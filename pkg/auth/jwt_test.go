@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test secret")
+
+// signToken собирает JWT из заголовка и claims в JSON, подписывая его
+// HS256 с secret
+func signToken(header, claims string, secret []byte) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	unsigned := encode([]byte(header)) + "." + encode([]byte(claims))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + encode(mac.Sum(nil))
+}
+
+// signatureOf возвращает подпись токена
+func signatureOf(token string) string {
+	return token[strings.LastIndex(token, ".")+1:]
+}
+
+// withSignature заменяет подпись токена на signature
+func withSignature(token, signature string) string {
+	return token[:strings.LastIndex(token, ".")+1] + signature
+}
+
+func TestJWTVerifier(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+	valid := signToken(hs256, `{"sub":"alice","exp":1700000600}`, testSecret)
+
+	tests := []struct {
+		name   string
+		token  string
+		issuer string
+		user   string
+		err    error
+	}{
+		{name: "valid", token: valid, user: "alice"},
+		{name: "no expiry", token: signToken(hs256, `{"sub":"alice"}`, testSecret), user: "alice"},
+		{name: "expired within skew", token: signToken(hs256, `{"sub":"alice","exp":1699999990}`, testSecret), user: "alice"},
+		{name: "issuer matches", token: signToken(hs256, `{"sub":"alice","iss":"game"}`, testSecret), issuer: "game", user: "alice"},
+		{name: "empty", token: "", err: ErrMissingToken},
+		{name: "not a jwt", token: "abc", err: ErrInvalidToken},
+		{name: "alg none", token: signToken(`{"alg":"none"}`, `{"sub":"alice"}`, testSecret), err: ErrInvalidToken},
+		{name: "alg none unsigned", token: withSignature(signToken(`{"alg":"none"}`, `{"sub":"alice"}`, testSecret), ""), err: ErrInvalidToken},
+		{name: "alg RS256", token: signToken(`{"alg":"RS256"}`, `{"sub":"alice"}`, testSecret), err: ErrInvalidToken},
+		{name: "alg lowercase", token: signToken(`{"alg":"hs256"}`, `{"sub":"alice"}`, testSecret), err: ErrInvalidToken},
+		{name: "wrong secret", token: signToken(hs256, `{"sub":"alice"}`, []byte("other")), err: ErrInvalidToken},
+		{name: "tampered claims", token: withSignature(signToken(hs256, `{"sub":"mallory"}`, testSecret), signatureOf(valid)), err: ErrInvalidToken},
+		{name: "signature not base64", token: withSignature(valid, "!!"), err: ErrInvalidToken},
+		{name: "expired", token: signToken(hs256, `{"sub":"alice","exp":1699999900}`, testSecret), err: ErrExpiredToken},
+		{name: "not yet valid", token: signToken(hs256, `{"sub":"alice","nbf":1700000600}`, testSecret), err: ErrInvalidToken},
+		{name: "wrong issuer", token: signToken(hs256, `{"sub":"alice","iss":"other"}`, testSecret), issuer: "game", err: ErrInvalidToken},
+		{name: "no subject", token: signToken(hs256, `{"exp":1700000600}`, testSecret), err: ErrInvalidToken},
+		{name: "claims not json", token: signToken(hs256, `sub=alice`, testSecret), err: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewJWTVerifier(testSecret)
+			verifier.Issuer = tt.issuer
+			verifier.now = func() time.Time { return now }
+
+			identity, err := verifier.Verify(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if identity.UserID != tt.user {
+				t.Fatalf("got user %q, want %q", identity.UserID, tt.user)
+			}
+		})
+	}
+}
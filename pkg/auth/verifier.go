@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	ErrMissingToken = errors.New("missing token")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+)
+
+// Identity - пользователь, которому принадлежит проверенный токен
+type Identity struct {
+	UserID string
+}
+
+// Verifier проверяет bearer токен и возвращает его владельца
+type Verifier interface {
+	Verify(token string) (Identity, error)
+}
+
+// StaticVerifier принимает заранее известные токены, например для
+// серверных ботов и локальной разработки
+type StaticVerifier struct {
+	tokens map[string]string
+}
+
+// NewStaticVerifier создает проверку по таблице токен -> пользователь
+func NewStaticVerifier(tokens map[string]string) *StaticVerifier {
+	return &StaticVerifier{tokens: tokens}
+}
+
+// LoadStaticTokens читает файл со строками "<токен> <пользователь>".
+// Пустые строки и строки, начинающиеся с #, пропускаются.
+func LoadStaticTokens(path string) (*StaticVerifier, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tokens := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"<token> <user>\"", path, line)
+		}
+		tokens[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewStaticVerifier(tokens), nil
+}
+
+func (v *StaticVerifier) Verify(token string) (Identity, error) {
+	if token == "" {
+		return Identity{}, ErrMissingToken
+	}
+
+	// сравниваем все токены за постоянное время, чтобы не подсказывать
+	// совпавший префикс
+	var userID string
+	for known, user := range v.tokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			userID = user
+		}
+	}
+	if userID == "" {
+		return Identity{}, ErrInvalidToken
+	}
+
+	return Identity{UserID: userID}, nil
+}
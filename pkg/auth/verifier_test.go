@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticVerifier(t *testing.T) {
+	verifier := NewStaticVerifier(map[string]string{
+		"bot-token": "bot",
+		"dev-token": "dev",
+	})
+
+	tests := []struct {
+		name  string
+		token string
+		user  string
+		err   error
+	}{
+		{name: "known", token: "dev-token", user: "dev"},
+		{name: "empty", token: "", err: ErrMissingToken},
+		{name: "unknown", token: "other", err: ErrInvalidToken},
+		{name: "prefix", token: "dev-", err: ErrInvalidToken},
+		{name: "longer", token: "dev-token2", err: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := verifier.Verify(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if identity.UserID != tt.user {
+				t.Fatalf("got user %q, want %q", identity.UserID, tt.user)
+			}
+		})
+	}
+}
+
+func TestLoadStaticTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		token   string
+		user    string
+		wantErr bool
+	}{
+		{name: "tokens", content: "# bots\n\nbot-token bot\n  dev-token   dev  \n", token: "dev-token", user: "dev"},
+		{name: "missing user", content: "bot-token\n", wantErr: true},
+		{name: "extra field", content: "bot-token bot admin\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tokens.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			verifier, err := LoadStaticTokens(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			identity, err := verifier.Verify(tt.token)
+			if err != nil || identity.UserID != tt.user {
+				t.Fatalf("got %q, %v, want %q", identity.UserID, err, tt.user)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"game_web_server/generated"
 	"game_web_server/pkg/entities"
 	"github.com/google/uuid"
+	"sync"
+	"time"
 )
 
 type TEvent = int
@@ -12,11 +14,52 @@ type TEvent = int
 const (
 	ENTITY_MOVE TEvent = iota
 	PLAYER_CONNECT
+	PLAYER_DISCONNECT
+	ENTITY_COLLISION
+	TRIGGER_ENTER
+	TRIGGER_EXIT
+	HIT
+	MISS
+	// CONNECTION_OPEN и CONNECTION_CLOSE - соединение игрока подключено к
+	// комнате или отключено от нее; сущность при этом не меняется.
+	// Data - network.ConnInfo.
+	CONNECTION_OPEN
+	CONNECTION_CLOSE
 )
 
+var eventNames = map[TEvent]string{
+	ENTITY_MOVE:       "entity_move",
+	PLAYER_CONNECT:    "player_connect",
+	PLAYER_DISCONNECT: "player_disconnect",
+	ENTITY_COLLISION:  "entity_collision",
+	TRIGGER_ENTER:     "trigger_enter",
+	TRIGGER_EXIT:      "trigger_exit",
+	HIT:               "hit",
+	MISS:              "miss",
+	CONNECTION_OPEN:   "connection_open",
+	CONNECTION_CLOSE:  "connection_close",
+}
+
+// EventName возвращает имя типа события для клиентов и логов
+func EventName(t TEvent) string {
+	if name, ok := eventNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("event_%d", t)
+}
+
+// DefaultTickRate - частота игрового цикла по умолчанию, тиков в секунду
+const DefaultTickRate = 20
+
+// inputQueueSize - сколько действий может ждать диспетчера, прежде чем
+// отправители начнут блокироваться
+const inputQueueSize = 1024
+
 type Event struct {
-	ID string
-	T  TEvent
+	ID     string
+	T      TEvent
+	Entity string
+	Data   any
 }
 
 func NewEvent(et TEvent) *Event {
@@ -26,82 +69,430 @@ func NewEvent(et TEvent) *Event {
 	}
 }
 
+var updateEvents = map[string]TEvent{
+	entities.UpdatePosition:     ENTITY_MOVE,
+	entities.UpdateCollision:    ENTITY_COLLISION,
+	entities.UpdateTriggerEnter: TRIGGER_ENTER,
+	entities.UpdateTriggerExit:  TRIGGER_EXIT,
+}
+
+// eventFromUpdate переводит изменение EntityManager в событие движка
+func eventFromUpdate(update entities.EntityUpdate) (*Event, bool) {
+	et, ok := updateEvents[update.Type]
+	if !ok {
+		return nil, false
+	}
+
+	event := NewEvent(et)
+	event.Entity = update.Name
+	event.Data = update.Data
+	return event, true
+}
+
 type SubscriberCallback = func(event *Event) error
 
-type ActionCallback = func() error
+type ActionCallback = func(action *Action) error
+
+// TickCallback вызывается на каждом тике с фиксированным шагом dt
+type TickCallback = func(dt time.Duration) error
+
+// Input - действие, пришедшее от клиента, вместе с его игроком
+type Input struct {
+	PlayerID string
+	Action   *generated.ClientAction
+}
 
 type Action struct {
-	ID   string
-	Name string
-	Key  string
+	ID       string
+	Name     string
+	Key      string
+	PlayerID string
+	Seq      uint32
+	// ViewTick - тик сервера, который клиент показывал в момент действия
+	ViewTick uint64
+	// AimX, AimY - направление прицела
+	AimX     float32
+	AimY     float32
+	callback ActionCallback
 }
 
 func (e *Engine) NewAction(name string, callback ActionCallback) *Action {
 	return &Action{
-		ID:   uuid.New().String(),
-		Name: name,
+		ID:       uuid.New().String(),
+		Name:     name,
+		callback: callback,
 	}
 }
 
+// Broadcaster рассылает клиентам изменения сущностей, накопленные за тик.
+// Broadcast вызывается на каждом тике, в том числе без изменений, чтобы
+// новые клиенты получили снапшот мира.
+type Broadcaster interface {
+	Broadcast(tick uint64, updates []entities.EntityUpdate)
+}
+
+// LatencySource сообщает сглаженный RTT соединения игрока; 0, если
+// соединения нет или замеров еще не было
+type LatencySource interface {
+	RTT(playerID string) time.Duration
+}
+
+type system struct {
+	name   string
+	onTick TickCallback
+}
+
 type Engine struct {
-	EntityManager entities.EntityManager
-	CActionChan chan *generated.ClientAction
+	EntityManager *entities.EntityManager
+	CActionChan   chan *Input
+	TickRate      int
+	// PlayerTemplate - из чего создается сущность нового игрока
+	PlayerTemplate entities.Entity
+
+	mut         sync.Mutex
+	tick        uint64
+	inputs      []*Action
+	handlers    map[string][]ActionCallback
+	systems     []system
 	subscribers map[string][]chan *Action
+	listeners   map[TEvent][]SubscriberCallback
+	events      []*Event
+	lastSeq     map[string]uint32
+	history     *PositionHistory
+	broadcaster Broadcaster
+	latency     LatencySource
+	started     bool
+	stopHooks   []func()
+	quit        chan struct{}
+	loopDone    chan struct{}
+	stopOnce    sync.Once
+}
+
+// SetBroadcaster задает получателя изменений, вызывать до Start
+func (e *Engine) SetBroadcaster(b Broadcaster) {
+	e.broadcaster = b
+}
+
+// SetLatencySource задает, откуда брать RTT игроков, вызывать до Start
+func (e *Engine) SetLatencySource(source LatencySource) {
+	e.latency = source
+}
+
+// RTT возвращает сглаженное время туда и обратно до клиента игрока для
+// компенсации задержки; 0, если оно неизвестно
+func (e *Engine) RTT(playerID string) time.Duration {
+	if e.latency == nil {
+		return 0
+	}
+	return e.latency.RTT(playerID)
 }
 
 func (e *Engine) Subscribe(actionName string) <-chan *Action {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
 	var channel = make(chan *Action, 1000)
 	e.subscribers[actionName] = append(e.subscribers[actionName], channel)
 	return channel
 }
 
+// RegisterAction регистрирует обработчик действия, созданного через NewAction.
+// Обработчик вызывается из игрового цикла для каждого ввода с этим именем.
+func (e *Engine) RegisterAction(action *Action) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	e.handlers[action.Name] = append(e.handlers[action.Name], action.callback)
+}
+
+// HasAction сообщает, есть ли у действия обработчик или подписчик.
+// Действия без них сервер отклоняет, не ставя в очередь.
+func (e *Engine) HasAction(name string) bool {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	return len(e.handlers[name]) > 0 || len(e.subscribers[name]) > 0
+}
+
+// RegisterSystem добавляет систему, которая выполняется на каждом тике.
+// Системы вызываются в порядке регистрации.
+func (e *Engine) RegisterSystem(name string, onTick TickCallback) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	e.systems = append(e.systems, system{name: name, onTick: onTick})
+}
+
+// On подписывает callback на события типа t.
+// Callback вызывается из игрового цикла.
+func (e *Engine) On(t TEvent, callback SubscriberCallback) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	e.listeners[t] = append(e.listeners[t], callback)
+}
+
+// Emit ставит событие в очередь, оно будет доставлено на ближайшем тике
+func (e *Engine) Emit(event *Event) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	e.events = append(e.events, event)
+}
+
+func (e *Engine) dispatchEvents() {
+	e.mut.Lock()
+	events := e.events
+	e.events = nil
+	e.mut.Unlock()
+
+	for _, event := range events {
+		e.mut.Lock()
+		listeners := append([]SubscriberCallback(nil), e.listeners[event.T]...)
+		e.mut.Unlock()
+
+		for _, listener := range listeners {
+			if err := listener(event); err != nil {
+				fmt.Println("Event listener error:", event.T, err)
+			}
+		}
+	}
+}
+
+// CurrentTick возвращает номер последнего обработанного тика
+func (e *Engine) CurrentTick() uint64 {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	return e.tick
+}
+
+// LastProcessedInput возвращает номер последнего ввода игрока, который
+// уже применен к миру. Клиент по нему отбрасывает подтвержденные вводы.
+func (e *Engine) LastProcessedInput(playerID string) uint32 {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	return e.lastSeq[playerID]
+}
+
+// dispatcher складывает пришедшие действия в очередь до следующего тика
 func (e *Engine) dispatcher() {
-	for cAction := range e.CActionChan {
-		actionName := string(cAction.Action())
-		keyPressed := string(cAction.Key())
+	for {
+		var input *Input
+		select {
+		case input = <-e.CActionChan:
+		case <-e.quit:
+			return
+		}
+
+		actionName := string(input.Action.Action())
+		keyPressed := string(input.Action.Key())
+
+		fmt.Println("Key pressed: ", keyPressed, "Action", actionName, "Player", input.PlayerID)
+
+		e.mut.Lock()
+		e.inputs = append(e.inputs, &Action{
+			ID:       uuid.New().String(),
+			Name:     actionName,
+			Key:      keyPressed,
+			PlayerID: input.PlayerID,
+			Seq:      input.Action.Seq(),
+			ViewTick: input.Action.ViewTick(),
+			AimX:     input.Action.AimX(),
+			AimY:     input.Action.AimY(),
+		})
+		e.mut.Unlock()
+	}
+}
 
-		fmt.Println("Key pressed: ", keyPressed, "Action", actionName)
+func (e *Engine) handleAction(action *Action, handlers []ActionCallback, subscribers []chan *Action) {
+	for _, handler := range handlers {
+		if err := handler(action); err != nil {
+			fmt.Println("Action handler error:", action.Name, err)
+		}
+	}
 
-		actionSubscribers := e.subscribers[actionName]
-		if len(actionSubscribers) == 0 {
-			continue
+	for _, subChan := range subscribers {
+		select {
+		case subChan <- action:
+		default:
+			fmt.Println("Action subscriber is full, dropping:", action.Name)
 		}
+	}
+}
 
-		for _, subChan := range actionSubscribers {
-			subChan <- &Action{
-				ID:   uuid.New().String(),
-				Name: actionName,
-				Key:  keyPressed,
-			}
+// step выполняет один тик: применяет накопленный ввод, прогоняет системы,
+// отправляет одну сводную дельту состояния и доставляет события
+func (e *Engine) step(dt time.Duration) {
+	e.mut.Lock()
+	e.tick++
+	tick := e.tick
+	inputs := e.inputs
+	e.inputs = nil
+	systems := append([]system(nil), e.systems...)
+	e.mut.Unlock()
+
+	for _, action := range inputs {
+		e.mut.Lock()
+		handlers := append([]ActionCallback(nil), e.handlers[action.Name]...)
+		subscribers := append([]chan *Action(nil), e.subscribers[action.Name]...)
+		e.mut.Unlock()
+
+		e.handleAction(action, handlers, subscribers)
+
+		e.mut.Lock()
+		if action.Seq > e.lastSeq[action.PlayerID] {
+			e.lastSeq[action.PlayerID] = action.Seq
+		}
+		e.mut.Unlock()
+	}
+
+	for _, s := range systems {
+		if err := s.onTick(dt); err != nil {
+			fmt.Println("System error:", s.name, err)
+		}
+	}
+
+	var states []entities.EntityUpdate
+	for _, update := range e.EntityManager.Flush() {
+		if event, ok := eventFromUpdate(update); ok {
+			e.Emit(event)
+		}
+		if !update.IsEvent() {
+			states = append(states, update)
+		}
+	}
+
+	e.history.Record(tick, states)
+
+	if e.broadcaster != nil {
+		e.broadcaster.Broadcast(tick, states)
+	}
+
+	e.dispatchEvents()
+}
+
+func (e *Engine) loop() {
+	defer close(e.loopDone)
+
+	dt := time.Second / time.Duration(e.TickRate)
+	ticker := time.NewTicker(dt)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.step(dt)
+		case <-e.quit:
+			return
 		}
 	}
 }
 
-func NewEngine() *Engine {
+func NewEngine(tickRate int) *Engine {
 	var manager = entities.NewEntityManager()
 	if err := manager.Init(); err != nil {
 		panic(err)
 	}
 
-	return &Engine{
-		EntityManager: *manager,
-		CActionChan: make(chan *generated.ClientAction),
-		subscribers: make(map[string][]chan *Action),
+	if tickRate <= 0 {
+		tickRate = DefaultTickRate
+	}
+
+	engine := &Engine{
+		EntityManager: manager,
+		CActionChan:   make(chan *Input, inputQueueSize),
+		TickRate:      tickRate,
+		handlers:      make(map[string][]ActionCallback),
+		subscribers:   make(map[string][]chan *Action),
+		listeners:     make(map[TEvent][]SubscriberCallback),
+		lastSeq:       make(map[string]uint32),
+		history:       NewPositionHistory(DefaultHistoryTicks, manager.All()),
+		quit:          make(chan struct{}),
+		loopDone:      make(chan struct{}),
 	}
+
+	engine.RegisterAction(engine.NewAction(MoveActionName, engine.movePlayer))
+
+	return engine
+}
+
+// PlayerConnected создает сущность игрока из PlayerTemplate, если ее еще
+// нет, и публикует PLAYER_CONNECT. Если игрок вернулся в пределах grace
+// period, его сущность остается прежней, а в Data события будет true.
+// owner - проверенный пользователь, которому принадлежит сущность.
+func (e *Engine) PlayerConnected(playerID, owner string) entities.Entity {
+	template := e.PlayerTemplate
+	template.Name = playerID
+	template.Owner = owner
+
+	entity, created := e.EntityManager.Spawn(template)
+
+	event := NewEvent(PLAYER_CONNECT)
+	event.Entity = playerID
+	event.Data = !created
+	e.Emit(event)
+
+	return entity
+}
+
+// PlayerDisconnected удаляет сущность игрока и публикует PLAYER_DISCONNECT
+func (e *Engine) PlayerDisconnected(playerID string) {
+	e.EntityManager.Remove(playerID)
+
+	e.mut.Lock()
+	delete(e.lastSeq, playerID)
+	e.mut.Unlock()
+
+	event := NewEvent(PLAYER_DISCONNECT)
+	event.Entity = playerID
+	e.Emit(event)
 }
 
 func (e *Engine) Start() {
+	e.mut.Lock()
+	e.started = true
+	e.mut.Unlock()
+
 	go e.dispatcher()
+	go e.loop()
+}
 
-	channelManager := e.EntityManager.Subscribe()
-	
-	go func () {
-		for change := range channelManager {
-			fmt.Println("Boardcast to players", change.Name, change.Type)
+// OnStop добавляет callback, который вызывается из Stop после остановки
+// игрового цикла, например, чтобы плагин освободил свои ресурсы
+func (e *Engine) OnStop(callback func()) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	e.stopHooks = append(e.stopHooks, callback)
+}
+
+// Stop останавливает игровой цикл и прием действий, дожидается конца
+// текущего тика, вызывает callback'и OnStop и закрывает каналы
+// EntityManager.Subscribe. Повторный вызов ничего не делает. Нельзя
+// вызывать из игрового цикла.
+func (e *Engine) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.quit)
+
+		e.mut.Lock()
+		started := e.started
+		hooks := append([]func(){}, e.stopHooks...)
+		e.mut.Unlock()
+
+		if started {
+			<-e.loopDone
+		}
+		for _, hook := range hooks {
+			hook()
 		}
-	}()
+		e.EntityManager.Close()
+	})
 }
 
-//func isOverlapping(x1, y1, w1, h1, x2, y2, w2, h2 int) bool {
-//	return x1 < x2+w2 && x1+w1 > x2 && y1+h1 > y2 && y1 < y2+h2
-//}
+// Done закрывается после Stop. Отправителям в CActionChan стоит ждать
+// его вместе с отправкой, чтобы не заблокироваться на остановленном движке.
+func (e *Engine) Done() <-chan struct{} {
+	return e.quit
+}
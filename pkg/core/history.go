@@ -0,0 +1,147 @@
+package core
+
+import (
+	"game_web_server/pkg/entities"
+	"sync"
+)
+
+// DefaultHistoryTicks - сколько последних тиков хранится для отката
+// позиций при проверке попаданий
+const DefaultHistoryTicks = 32
+
+// historyFrame - сущности, изменившиеся за тик, с их состоянием после
+// него. nil означает, что сущность удалена.
+type historyFrame struct {
+	tick    uint64
+	changed map[string]*entities.Entity
+}
+
+// PositionHistory - кольцевой буфер изменений мира за последние тики.
+// Неподвижные сущности в нем не копируются: их состояние берется из base.
+type PositionHistory struct {
+	mut    sync.RWMutex
+	frames []historyFrame
+	next   int
+	count  int
+	// base - состояние мира до самого старого тика в буфере
+	base map[string]entities.Entity
+}
+
+// NewPositionHistory создает буфер на size тиков. world - состояние мира
+// до первого записанного тика.
+func NewPositionHistory(size int, world []entities.Entity) *PositionHistory {
+	if size <= 0 {
+		size = DefaultHistoryTicks
+	}
+
+	base := make(map[string]entities.Entity, len(world))
+	for _, entity := range world {
+		base[entity.Name] = entity
+	}
+
+	return &PositionHistory{
+		frames: make([]historyFrame, size),
+		base:   base,
+	}
+}
+
+// Record запоминает изменения сущностей на тике tick (события
+// пропускаются). Самый старый тик, если буфер заполнен, вливается в base.
+func (h *PositionHistory) Record(tick uint64, updates []entities.EntityUpdate) {
+	frame := historyFrame{
+		tick:    tick,
+		changed: make(map[string]*entities.Entity, len(updates)),
+	}
+	for _, update := range updates {
+		switch {
+		case update.IsEvent():
+		case update.Type == entities.UpdateDespawn:
+			frame.changed[update.Name] = nil
+		default:
+			state := update.State
+			frame.changed[update.Name] = &state
+		}
+	}
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	if h.count == len(h.frames) {
+		for name, state := range h.frames[h.next].changed {
+			if state == nil {
+				delete(h.base, name)
+			} else {
+				h.base[name] = *state
+			}
+		}
+	}
+
+	h.frames[h.next] = frame
+	h.next = (h.next + 1) % len(h.frames)
+	if h.count < len(h.frames) {
+		h.count++
+	}
+}
+
+// At откатывает мир к последнему записанному тику не позже tick и
+// возвращает его номер и состояния сущностей names, а также всех
+// сущностей, изменившихся после него, - только тех, что тогда
+// существовали. Если tick старше буфера, берется самый старый тик; если
+// буфер пуст - false.
+func (h *PositionHistory) At(tick uint64, names []string) (map[string]entities.Entity, uint64, bool) {
+	h.mut.RLock()
+	defer h.mut.RUnlock()
+
+	if h.count == 0 {
+		return nil, 0, false
+	}
+
+	size := len(h.frames)
+	// frames[i] - i-й тик с конца
+	frames := make([]*historyFrame, h.count)
+	for i := range frames {
+		frames[i] = &h.frames[(h.next-1-i+size)%size]
+	}
+
+	at := len(frames) - 1
+	for i, frame := range frames {
+		if frame.tick <= tick {
+			at = i
+			break
+		}
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	for _, frame := range frames[:at] {
+		for name := range frame.changed {
+			wanted[name] = true
+		}
+	}
+
+	world := make(map[string]entities.Entity, len(wanted))
+	for name := range wanted {
+		if state, ok := h.stateAt(frames[at:], name); ok {
+			world[name] = state
+		}
+	}
+	return world, frames[at].tick, true
+}
+
+// stateAt ищет последнее состояние сущности в frames (от новых к старым),
+// а если она в них не менялась - в base
+func (h *PositionHistory) stateAt(frames []*historyFrame, name string) (entities.Entity, bool) {
+	for _, frame := range frames {
+		if state, ok := frame.changed[name]; ok {
+			if state == nil {
+				return entities.Entity{}, false
+			}
+			return *state, true
+		}
+	}
+
+	state, ok := h.base[name]
+	return state, ok
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"game_web_server/pkg/entities"
+	"math"
+	"time"
+)
+
+// DefaultShotRange - дальность выстрела в пикселях по умолчанию
+const DefaultShotRange = 1000
+
+// RayHit - результат луча: в какую сущность он попал и где
+type RayHit struct {
+	Shooter  string            `json:"shooter"`
+	Target   entities.Entity   `json:"target"`
+	Point    entities.Position `json:"point"`
+	Distance float64           `json:"distance"`
+	// Tick - тик, на состояние которого откатывался мир
+	Tick uint64 `json:"tick"`
+}
+
+// rayIntersect возвращает расстояние вдоль луча до прямоугольника
+// сущности (метод плоскостей). Направление должно быть нормализовано.
+func rayIntersect(ox, oy, dx, dy float64, entity entities.Entity) (float64, bool) {
+	tMin, tMax := math.Inf(-1), math.Inf(1)
+
+	axes := [2][4]float64{
+		{ox, dx, float64(entity.X), float64(entity.X + entity.Width)},
+		{oy, dy, float64(entity.Y), float64(entity.Y + entity.Height)},
+	}
+	for _, axis := range axes {
+		origin, dir, lo, hi := axis[0], axis[1], axis[2], axis[3]
+		if dir == 0 {
+			if origin < lo || origin > hi {
+				return 0, false
+			}
+			continue
+		}
+
+		t1, t2 := (lo-origin)/dir, (hi-origin)/dir
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tMin, tMax = math.Max(tMin, t1), math.Min(tMax, t2)
+	}
+
+	if tMax < 0 || tMin > tMax {
+		return 0, false
+	}
+	return math.Max(tMin, 0), true
+}
+
+// Raycast пускает луч из origin в направлении (dirX, dirY) по состоянию
+// мира на тике atTick и возвращает ближайшую твердую сущность не дальше
+// maxDistance. Сущность ignore (обычно сам стрелок) пропускается. Тик
+// ограничивается буфером истории, поэтому слишком старый atTick
+// откатывает мир не дальше DefaultHistoryTicks назад.
+func (e *Engine) Raycast(origin entities.Position, dirX, dirY float64, maxDistance int, atTick uint64, ignore string) (*RayHit, bool) {
+	length := math.Hypot(dirX, dirY)
+	if length == 0 {
+		return nil, false
+	}
+	dirX, dirY = dirX/length, dirY/length
+
+	if current := e.CurrentTick(); atTick == 0 || atTick > current {
+		atTick = current
+	}
+
+	ox, oy := float64(origin.X), float64(origin.Y)
+
+	// кандидаты - сущности, которые сейчас у луча, и те, чьи изменения еще
+	// не записаны в историю; менявшиеся после atTick история добавит сама.
+	// Остальные стоят там же, где стояли на atTick.
+	endX, endY := ox+dirX*float64(maxDistance), oy+dirY*float64(maxDistance)
+	corner := entities.Position{X: int(math.Floor(math.Min(ox, endX))), Y: int(math.Floor(math.Min(oy, endY)))}
+	size := entities.Size{
+		Width:  int(math.Ceil(math.Max(ox, endX))) - corner.X + 1,
+		Height: int(math.Ceil(math.Max(oy, endY))) - corner.Y + 1,
+	}
+	names := e.EntityManager.Pending()
+	for _, entity := range e.EntityManager.QueryRect(corner, size) {
+		names = append(names, entity.Name)
+	}
+
+	world, tick, ok := e.history.At(atTick, names)
+	if !ok {
+		return nil, false
+	}
+
+	var best *RayHit
+	for name, entity := range world {
+		if name == ignore || !entity.IsCollision {
+			continue
+		}
+
+		distance, hit := rayIntersect(ox, oy, dirX, dirY, entity)
+		if !hit || distance > float64(maxDistance) {
+			continue
+		}
+
+		if best == nil || distance < best.Distance {
+			best = &RayHit{
+				Shooter: ignore,
+				Target:  entity,
+				Point: entities.Position{
+					X: int(math.Round(ox + dirX*distance)),
+					Y: int(math.Round(oy + dirY*distance)),
+				},
+				Distance: distance,
+				Tick:     tick,
+			}
+		}
+	}
+
+	return best, best != nil
+}
+
+// Shoot проверяет выстрел игрока с компенсацией задержки: луч идет из
+// центра стрелка в направлении прицела по миру, каким его видел клиент на
+// action.ViewTick (или по оценке из RTT, если клиент его не прислал).
+// Результат публикуется событием HIT (Data - *RayHit) или MISS.
+func (e *Engine) Shoot(action *Action, maxDistance int) (*RayHit, bool) {
+	shooter := e.EntityManager.GetByName(action.PlayerID)
+	if shooter == nil {
+		return nil, false
+	}
+
+	origin := entities.Position{
+		X: shooter.X + shooter.Width/2,
+		Y: shooter.Y + shooter.Height/2,
+	}
+
+	hit, ok := e.Raycast(origin, float64(action.AimX), float64(action.AimY), maxDistance, e.viewTick(action), action.PlayerID)
+
+	event := NewEvent(MISS)
+	if ok {
+		event = NewEvent(HIT)
+		event.Data = hit
+	}
+	event.Entity = action.PlayerID
+	e.Emit(event)
+
+	return hit, ok
+}
+
+// InterpolationDelay - насколько клиент отстает при отрисовке чужих
+// сущностей. Сервер учитывает его, ограничивая откат при проверке попаданий.
+const InterpolationDelay = 100 * time.Millisecond
+
+// rewindMargin - запас на джиттер сети сверх RTT/2 и InterpolationDelay
+const rewindMargin = 50 * time.Millisecond
+
+// viewTick возвращает тик, который видел клиент в момент действия. Если
+// клиент его не прислал, тик оценивается по половине RTT игрока. Тик
+// клиента не может быть старше, чем позволяют его RTT/2,
+// InterpolationDelay и rewindMargin, иначе клиент мог бы откатывать
+// проверку к самому старому тику истории.
+func (e *Engine) viewTick(action *Action) uint64 {
+	current := e.CurrentTick()
+	if current == 0 {
+		return 0
+	}
+
+	tick := time.Second / time.Duration(e.TickRate)
+	halfRTT := e.RTT(action.PlayerID) / 2
+
+	maxRewind := uint64((halfRTT + InterpolationDelay + rewindMargin + tick - 1) / tick)
+	if maxRewind >= current {
+		maxRewind = current - 1
+	}
+	oldest := current - maxRewind
+
+	viewTick := action.ViewTick
+	if viewTick == 0 {
+		lag := uint64(halfRTT / tick)
+		if lag >= current {
+			lag = current - 1
+		}
+		viewTick = current - lag
+	}
+	return min(max(viewTick, oldest), current)
+}
@@ -0,0 +1,40 @@
+package core
+
+// MoveActionName - действие перемещения игрока. Его обрабатывает сам
+// движок, а клиент по тем же правилам предсказывает свое движение.
+const MoveActionName = "player_move"
+
+// PlayerSpeed - на сколько пикселей сдвигает игрока одно нажатие
+const PlayerSpeed = 5
+
+// MoveDelta возвращает смещение игрока для нажатой клавиши
+func MoveDelta(key string) (int, int) {
+	switch key {
+	case "W", "↑":
+		return 0, -PlayerSpeed
+	case "S", "↓":
+		return 0, PlayerSpeed
+	case "A", "←":
+		return -PlayerSpeed, 0
+	case "D", "→":
+		return PlayerSpeed, 0
+	default:
+		return 0, 0
+	}
+}
+
+// IsMoveKey сообщает, перемещает ли клавиша игрока
+func IsMoveKey(key string) bool {
+	dx, dy := MoveDelta(key)
+	return dx != 0 || dy != 0
+}
+
+func (e *Engine) movePlayer(action *Action) error {
+	dx, dy := MoveDelta(action.Key)
+	if dx == 0 && dy == 0 {
+		return nil
+	}
+
+	e.EntityManager.MoveBy(action.PlayerID, dx, dy)
+	return nil
+}
@@ -0,0 +1,97 @@
+package entities
+
+func isOverlapping(x1, y1, w1, h1, x2, y2, w2, h2 int) bool {
+	return x1 < x2+w2 && x1+w1 > x2 && y1+h1 > y2 && y1 < y2+h2
+}
+
+// OverlapsAt проверяет, пересечется ли сущность с other, если встанет в pos
+func (e *Entity) OverlapsAt(pos Position, other *Entity) bool {
+	return isOverlapping(pos.X, pos.Y, e.Width, e.Height, other.X, other.Y, other.Width, other.Height)
+}
+
+// Overlaps проверяет пересечение сущностей в их текущих позициях
+func (e *Entity) Overlaps(other *Entity) bool {
+	return e.OverlapsAt(e.Position, other)
+}
+
+// blockers возвращает твердые сущности, с которыми entity пересечется в pos
+func (em *EntityManager) blockers(entity *Entity, pos Position) []*Entity {
+	var result []*Entity
+	for _, other := range em.index.QueryRect(pos.X, pos.Y, entity.Width, entity.Height) {
+		if other != entity && other.IsCollision {
+			result = append(result, other)
+		}
+	}
+	return result
+}
+
+// triggers возвращает имена нетвердых сущностей (триггеров), которые
+// пересекает entity в позиции pos
+func (em *EntityManager) triggers(entity *Entity, pos Position) map[string]bool {
+	result := make(map[string]bool)
+	for _, other := range em.index.QueryRect(pos.X, pos.Y, entity.Width, entity.Height) {
+		if other != entity && !other.IsCollision {
+			result[other.Name] = true
+		}
+	}
+	return result
+}
+
+// resolveMove подбирает позицию для твердой сущности: целевую, если она
+// свободна, иначе скольжение вдоль одной из осей, иначе текущую.
+// Возвращает итоговую позицию и сущности, которые помешали движению.
+func (em *EntityManager) resolveMove(entity *Entity, target Position) (Position, []*Entity) {
+	if !entity.IsCollision {
+		return target, nil
+	}
+
+	hits := em.blockers(entity, target)
+	if len(hits) == 0 {
+		return target, nil
+	}
+
+	candidates := []Position{
+		{X: target.X, Y: entity.Y},
+		{X: entity.X, Y: target.Y},
+	}
+	for _, candidate := range candidates {
+		if candidate == entity.Position {
+			continue
+		}
+		if len(em.blockers(entity, candidate)) == 0 {
+			return candidate, hits
+		}
+	}
+
+	return entity.Position, hits
+}
+
+// maxSpawnRings - на сколько шагов от исходной точки ищется свободное место
+const maxSpawnRings = 10
+
+// freePosition ищет ближайшую к pos позицию, где entity ни с кем не
+// пересекается, обходя кольца с шагом в размер сущности. Если места нет,
+// возвращает pos.
+func (em *EntityManager) freePosition(entity *Entity, pos Position) Position {
+	if !entity.IsCollision || len(em.blockers(entity, pos)) == 0 {
+		return pos
+	}
+
+	stepX, stepY := max(entity.Width, 1), max(entity.Height, 1)
+	for ring := 1; ring <= maxSpawnRings; ring++ {
+		for dx := -ring; dx <= ring; dx++ {
+			for dy := -ring; dy <= ring; dy++ {
+				if dx != -ring && dx != ring && dy != -ring && dy != ring {
+					continue
+				}
+
+				candidate := Position{X: pos.X + dx*stepX, Y: pos.Y + dy*stepY}
+				if len(em.blockers(entity, candidate)) == 0 {
+					return candidate
+				}
+			}
+		}
+	}
+
+	return pos
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 type Position struct {
@@ -18,51 +19,172 @@ type Size struct {
 }
 
 //TODO: Сделать Observer который наблюдает за изменениями
-//TODO: Сделать Обработку колизий и тригеров
-//TODO: Сделать отправку на клент если что-то изменилось
 
 type Entity struct {
 	Name        string `json:"name"`
 	Image       string `json:"image"`
 	IsCollision bool   `json:"is_collision"`
+	// Owner - пользователь, которому принадлежит сущность (для сущностей
+	// игроков - проверенный при подключении)
+	Owner string `json:"owner,omitempty"`
 	Position
 	Size
 }
 
+const (
+	UpdateSpawn        = "spawn"
+	UpdateDespawn      = "despawn"
+	UpdatePosition     = "position"
+	UpdateCollision    = "collision"
+	UpdateTriggerEnter = "trigger_enter"
+	UpdateTriggerExit  = "trigger_exit"
+)
+
 type EntityUpdate struct {
-	Name string
-	Type string
-	Data any
+	Name  string
+	Type  string
+	Data  any
+	State Entity
 }
 
+// IsEvent сообщает, что изменение описывает событие (столкновение,
+// триггер), а не новое состояние сущности
+func (u EntityUpdate) IsEvent() bool {
+	switch u.Type {
+	case UpdateCollision, UpdateTriggerEnter, UpdateTriggerExit:
+		return true
+	default:
+		return false
+	}
+}
+
+// EntityManager хранит сущности мира. Все методы безопасны для вызова из
+// нескольких горутин; наружу отдаются только копии сущностей.
 type EntityManager struct {
-	Entities
+	mut         sync.RWMutex
+	entities    Entities
 	subscribers []chan EntityUpdate
+	closed      bool
+	pending     []EntityUpdate
+	pendingIdx  map[string]int
+	index       *SpatialGrid
 }
 
 func NewEntityManager() *EntityManager {
 	return &EntityManager{
-		Entities:    make(Entities),
-		subscribers: make([]chan EntityUpdate, 100),
+		entities:    make(Entities),
+		subscribers: make([]chan EntityUpdate, 0, 100),
+		pendingIdx:  make(map[string]int),
+		index:       NewSpatialGrid(DefaultCellSize),
 	}
 }
 
 func (em *EntityManager) Init() error {
+	em.mut.Lock()
+	defer em.mut.Unlock()
+
 	entityLoader := NewEntitiesLoader("entities")
-	if err := entityLoader.Load(&em.Entities); err != nil {
+	if err := entityLoader.Load(&em.entities); err != nil {
 		return err
 	}
 
+	for _, entity := range em.entities {
+		em.index.Insert(entity)
+	}
+
 	return nil
 }
 
+func copyEntities(list []*Entity) []Entity {
+	result := make([]Entity, 0, len(list))
+	for _, entity := range list {
+		result = append(result, *entity)
+	}
+	return result
+}
+
+// All возвращает копии всех сущностей мира
+func (em *EntityManager) All() []Entity {
+	em.mut.RLock()
+	defer em.mut.RUnlock()
+
+	result := make([]Entity, 0, len(em.entities))
+	for _, entity := range em.entities {
+		result = append(result, *entity)
+	}
+	return result
+}
+
+// QueryRect возвращает сущности, пересекающие прямоугольник
+func (em *EntityManager) QueryRect(pos Position, size Size) []Entity {
+	em.mut.RLock()
+	defer em.mut.RUnlock()
+
+	return copyEntities(em.index.QueryRect(pos.X, pos.Y, size.Width, size.Height))
+}
+
+// QueryRadius возвращает сущности не дальше radius от точки
+func (em *EntityManager) QueryRadius(center Position, radius int) []Entity {
+	em.mut.RLock()
+	defer em.mut.RUnlock()
+
+	return copyEntities(em.index.QueryRadius(center.X, center.Y, radius))
+}
+
+// Nearest возвращает ближайшую к точке сущность, прошедшую filter.
+// filter вызывается под блокировкой менеджера и не должен обращаться к нему.
+func (em *EntityManager) Nearest(center Position, maxRadius int, filter func(*Entity) bool) *Entity {
+	em.mut.RLock()
+	defer em.mut.RUnlock()
+
+	nearest := em.index.Nearest(center.X, center.Y, maxRadius, filter)
+	if nearest == nil {
+		return nil
+	}
+
+	result := *nearest
+	return &result
+}
+
 func (em *EntityManager) Subscribe() <- chan EntityUpdate {
-	var channel = make(chan EntityUpdate)
+	em.mut.Lock()
+	defer em.mut.Unlock()
+
+	var channel = make(chan EntityUpdate, 1000)
+	if em.closed {
+		close(channel)
+		return channel
+	}
 	em.subscribers = append(em.subscribers, channel)
 	return channel
 }
 
+// Close закрывает каналы подписчиков, чтобы их горутины завершились.
+// Изменения после Close подписчикам уже не рассылаются.
+func (em *EntityManager) Close() {
+	em.mut.Lock()
+	defer em.mut.Unlock()
+
+	if em.closed {
+		return
+	}
+	em.closed = true
+	for _, sub := range em.subscribers {
+		close(sub)
+	}
+	em.subscribers = nil
+}
+
 func (em *EntityManager) notify(update EntityUpdate) {
+	if update.IsEvent() {
+		em.pending = append(em.pending, update)
+	} else if idx, ok := em.pendingIdx[update.Name]; ok {
+		em.pending[idx] = update
+	} else {
+		em.pendingIdx[update.Name] = len(em.pending)
+		em.pending = append(em.pending, update)
+	}
+
 	for _, sub := range em.subscribers {
 		select {
 		case sub <- update:
@@ -72,30 +194,190 @@ func (em *EntityManager) notify(update EntityUpdate) {
 	}
 }
 
+// Flush возвращает изменения, накопленные с прошлого вызова, и очищает
+// очередь. Состояние каждой сущности схлопывается до последнего, события
+// идут все по порядку.
+func (em *EntityManager) Flush() []EntityUpdate {
+	em.mut.Lock()
+	defer em.mut.Unlock()
+
+	updates := em.pending
+	em.pending = nil
+	clear(em.pendingIdx)
+	return updates
+}
+
+// Pending возвращает имена сущностей, состояние которых изменилось после
+// последнего Flush
+func (em *EntityManager) Pending() []string {
+	em.mut.RLock()
+	defer em.mut.RUnlock()
+
+	names := make([]string, 0, len(em.pendingIdx))
+	for name := range em.pendingIdx {
+		names = append(names, name)
+	}
+	return names
+}
+
+// GetByName возвращает копию сущности или nil, если ее нет.
+// Чтобы изменить сущность, используйте методы менеджера.
 func (em *EntityManager) GetByName(name string) *Entity {
-		return em.Entities[name]
-}
-
-func (em *EntityManager) SetPosition(name string, newPos Position) {
-	if entity, ok := em.Entities[name]; ok {
-		if entity.Position.X != newPos.X || entity.Position.Y != newPos.Y {
-			entity.X = newPos.X
-			entity.Y = newPos.Y
-
-			em.notify(EntityUpdate{
-				Name: name,
-				Type: "position",
-				Data: map[string]int{
-					"x": newPos.X,
-					"y": newPos.Y,
-				},
-			})
+	em.mut.RLock()
+	defer em.mut.RUnlock()
+
+	entity, ok := em.entities[name]
+	if !ok {
+		return nil
+	}
+
+	result := *entity
+	return &result
+}
+
+// SetPosition перемещает сущность с учетом столкновений и возвращает
+// позицию, в которой она оказалась. Столкновения и вход/выход из
+// триггеров публикуются как отдельные изменения.
+func (em *EntityManager) SetPosition(name string, newPos Position) Position {
+	em.mut.Lock()
+	defer em.mut.Unlock()
+
+	entity, ok := em.entities[name]
+	if !ok {
+		return newPos
+	}
+
+	return em.moveTo(entity, newPos)
+}
+
+// MoveBy сдвигает сущность на (dx, dy) относительно текущей позиции.
+// В отличие от пары GetByName + SetPosition, чтение и запись атомарны.
+func (em *EntityManager) MoveBy(name string, dx, dy int) Position {
+	em.mut.Lock()
+	defer em.mut.Unlock()
+
+	entity, ok := em.entities[name]
+	if !ok {
+		return Position{}
+	}
+
+	return em.moveTo(entity, Position{X: entity.X + dx, Y: entity.Y + dy})
+}
+
+// moveTo вызывается под блокировкой менеджера
+func (em *EntityManager) moveTo(entity *Entity, newPos Position) Position {
+	name := entity.Name
+
+	resolved, hits := em.resolveMove(entity, newPos)
+	for _, hit := range hits {
+		em.notify(EntityUpdate{
+			Name:  name,
+			Type:  UpdateCollision,
+			Data:  hit.Name,
+			State: *entity,
+		})
+	}
+
+	if entity.Position == resolved {
+		return resolved
+	}
+
+	triggersBefore := em.triggers(entity, entity.Position)
+	triggersAfter := em.triggers(entity, resolved)
+
+	entity.X = resolved.X
+	entity.Y = resolved.Y
+	em.index.Update(entity)
+
+	em.notify(EntityUpdate{
+		Name: name,
+		Type: UpdatePosition,
+		Data: map[string]int{
+			"x": resolved.X,
+			"y": resolved.Y,
+		},
+		State: *entity,
+	})
+
+	for trigger := range triggersAfter {
+		if !triggersBefore[trigger] {
+			em.notify(EntityUpdate{Name: name, Type: UpdateTriggerEnter, Data: trigger, State: *entity})
 		}
 	}
+	for trigger := range triggersBefore {
+		if !triggersAfter[trigger] {
+			em.notify(EntityUpdate{Name: name, Type: UpdateTriggerExit, Data: trigger, State: *entity})
+		}
+	}
+
+	return resolved
+}
+
+// Spawn добавляет сущность в мир. Если позиция занята твердой сущностью,
+// она сдвигается на ближайшее свободное место. Возвращает false, если
+// сущность с таким именем уже есть.
+func (em *EntityManager) Spawn(entity Entity) (Entity, bool) {
+	em.mut.Lock()
+	defer em.mut.Unlock()
+
+	if existing, ok := em.entities[entity.Name]; ok {
+		return *existing, false
+	}
+
+	spawned := entity
+	spawned.Position = em.freePosition(&spawned, entity.Position)
+	em.entities[spawned.Name] = &spawned
+	em.index.Insert(&spawned)
+
+	em.notify(EntityUpdate{
+		Name:  spawned.Name,
+		Type:  UpdateSpawn,
+		State: spawned,
+	})
+
+	return spawned, true
+}
+
+// Remove удаляет сущность из мира
+func (em *EntityManager) Remove(name string) bool {
+	em.mut.Lock()
+	defer em.mut.Unlock()
+
+	entity, ok := em.entities[name]
+	if !ok {
+		return false
+	}
+
+	delete(em.entities, name)
+	em.index.Remove(name)
+
+	em.notify(EntityUpdate{
+		Name:  name,
+		Type:  UpdateDespawn,
+		State: *entity,
+	})
+
+	return true
 }
 
 type Entities map[string]*Entity
 
+// LoadTemplate читает одну сущность из JSON файла, например шаблон игрока
+func LoadTemplate(path string) (Entity, error) {
+	var entity Entity
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entity, err
+	}
+
+	if err := json.Unmarshal(data, &entity); err != nil {
+		return entity, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+
+	return entity, nil
+}
+
 type EntityLoader struct {
 	InputDir string
 	Entities
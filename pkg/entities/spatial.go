@@ -0,0 +1,197 @@
+package entities
+
+import "math"
+
+// DefaultCellSize - размер ячейки пространственной сетки в пикселях
+const DefaultCellSize = 128
+
+type cellKey struct {
+	X int
+	Y int
+}
+
+// SpatialGrid - равномерная сетка для быстрых пространственных запросов.
+// Сущность хранится во всех ячейках, которые покрывает ее прямоугольник.
+type SpatialGrid struct {
+	cellSize int
+	cells    map[cellKey]map[string]*Entity
+	occupied map[string][]cellKey
+}
+
+// NewSpatialGrid создает пустую сетку с заданным размером ячейки
+func NewSpatialGrid(cellSize int) *SpatialGrid {
+	if cellSize <= 0 {
+		cellSize = DefaultCellSize
+	}
+
+	return &SpatialGrid{
+		cellSize: cellSize,
+		cells:    make(map[cellKey]map[string]*Entity),
+		occupied: make(map[string][]cellKey),
+	}
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// cellRange возвращает диапазон ячеек, покрываемых прямоугольником
+func (g *SpatialGrid) cellRange(x, y, w, h int) (cellKey, cellKey) {
+	maxX, maxY := x, y
+	if w > 0 {
+		maxX = x + w - 1
+	}
+	if h > 0 {
+		maxY = y + h - 1
+	}
+
+	return cellKey{floorDiv(x, g.cellSize), floorDiv(y, g.cellSize)},
+		cellKey{floorDiv(maxX, g.cellSize), floorDiv(maxY, g.cellSize)}
+}
+
+// Insert добавляет сущность в сетку; если она уже есть, обновляет ячейки
+func (g *SpatialGrid) Insert(entity *Entity) {
+	g.Remove(entity.Name)
+
+	from, to := g.cellRange(entity.X, entity.Y, entity.Width, entity.Height)
+	keys := make([]cellKey, 0, (to.X-from.X+1)*(to.Y-from.Y+1))
+	for cx := from.X; cx <= to.X; cx++ {
+		for cy := from.Y; cy <= to.Y; cy++ {
+			key := cellKey{cx, cy}
+			cell, ok := g.cells[key]
+			if !ok {
+				cell = make(map[string]*Entity)
+				g.cells[key] = cell
+			}
+			cell[entity.Name] = entity
+			keys = append(keys, key)
+		}
+	}
+	g.occupied[entity.Name] = keys
+}
+
+// Remove удаляет сущность из сетки
+func (g *SpatialGrid) Remove(name string) {
+	for _, key := range g.occupied[name] {
+		cell := g.cells[key]
+		delete(cell, name)
+		if len(cell) == 0 {
+			delete(g.cells, key)
+		}
+	}
+	delete(g.occupied, name)
+}
+
+// Update переносит сущность в ячейки, соответствующие ее новой позиции
+func (g *SpatialGrid) Update(entity *Entity) {
+	from, to := g.cellRange(entity.X, entity.Y, entity.Width, entity.Height)
+	keys := g.occupied[entity.Name]
+	if len(keys) > 0 && keys[0] == from && keys[len(keys)-1] == to {
+		return
+	}
+
+	g.Insert(entity)
+}
+
+// QueryRect возвращает сущности, пересекающие прямоугольник
+func (g *SpatialGrid) QueryRect(x, y, w, h int) []*Entity {
+	from, to := g.cellRange(x, y, w, h)
+	seen := make(map[string]bool)
+
+	var result []*Entity
+	for cx := from.X; cx <= to.X; cx++ {
+		for cy := from.Y; cy <= to.Y; cy++ {
+			for name, entity := range g.cells[cellKey{cx, cy}] {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+
+				if isOverlapping(x, y, w, h, entity.X, entity.Y, entity.Width, entity.Height) {
+					result = append(result, entity)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// distanceSq - квадрат расстояния от точки до прямоугольника сущности
+func distanceSq(x, y int, entity *Entity) int {
+	dx, dy := 0, 0
+	if x < entity.X {
+		dx = entity.X - x
+	} else if x > entity.X+entity.Width {
+		dx = x - entity.X - entity.Width
+	}
+	if y < entity.Y {
+		dy = entity.Y - y
+	} else if y > entity.Y+entity.Height {
+		dy = y - entity.Y - entity.Height
+	}
+	return dx*dx + dy*dy
+}
+
+// QueryRadius возвращает сущности, прямоугольник которых находится не
+// дальше radius от точки (x, y)
+func (g *SpatialGrid) QueryRadius(x, y, radius int) []*Entity {
+	var result []*Entity
+	for _, entity := range g.QueryRect(x-radius, y-radius, 2*radius+1, 2*radius+1) {
+		if distanceSq(x, y, entity) <= radius*radius {
+			result = append(result, entity)
+		}
+	}
+	return result
+}
+
+// Nearest возвращает ближайшую к точке сущность, прошедшую filter, не
+// дальше maxRadius. filter может быть nil.
+func (g *SpatialGrid) Nearest(x, y, maxRadius int, filter func(*Entity) bool) *Entity {
+	center := cellKey{floorDiv(x, g.cellSize), floorDiv(y, g.cellSize)}
+	maxRing := maxRadius/g.cellSize + 1
+
+	var best *Entity
+	bestDist := math.MaxInt
+	seen := make(map[string]bool)
+
+	for ring := 0; ring <= maxRing; ring++ {
+		// все, что дальше текущего кольца, не может быть ближе найденного
+		if best != nil {
+			ringDist := (ring - 1) * g.cellSize
+			if ringDist > 0 && ringDist*ringDist > bestDist {
+				break
+			}
+		}
+
+		for cx := center.X - ring; cx <= center.X+ring; cx++ {
+			for cy := center.Y - ring; cy <= center.Y+ring; cy++ {
+				if cx != center.X-ring && cx != center.X+ring && cy != center.Y-ring && cy != center.Y+ring {
+					continue
+				}
+
+				for name, entity := range g.cells[cellKey{cx, cy}] {
+					if seen[name] {
+						continue
+					}
+					seen[name] = true
+
+					if filter != nil && !filter(entity) {
+						continue
+					}
+
+					dist := distanceSq(x, y, entity)
+					if dist <= maxRadius*maxRadius && dist < bestDist {
+						best = entity
+						bestDist = dist
+					}
+				}
+			}
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,122 @@
+package entities
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const testCellSize = 10
+
+func newTestEntity(name string, x, y, w, h int) *Entity {
+	return &Entity{Name: name, Position: Position{X: x, Y: y}, Size: Size{Width: w, Height: h}}
+}
+
+// newTestGrid возвращает сетку с ячейками 10x10 и сущностями:
+// a внутри одной ячейки, big на отрицательных координатах и нескольких
+// ячейках, edge вплотную справа от a, far далеко от остальных
+func newTestGrid() *SpatialGrid {
+	grid := NewSpatialGrid(testCellSize)
+	for _, entity := range []*Entity{
+		newTestEntity("a", 0, 0, 5, 5),
+		newTestEntity("big", -15, -15, 30, 30),
+		newTestEntity("edge", 25, 0, 5, 5),
+		newTestEntity("far", 100, 100, 5, 5),
+	} {
+		grid.Insert(entity)
+	}
+	return grid
+}
+
+func names(list []*Entity) []string {
+	result := []string{}
+	for _, entity := range list {
+		result = append(result, entity.Name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestSpatialGridQueryRect(t *testing.T) {
+	tests := []struct {
+		name       string
+		x, y, w, h int
+		want       []string
+	}{
+		{name: "inside two entities", x: 1, y: 1, w: 2, h: 2, want: []string{"a", "big"}},
+		{name: "touching edge is not overlap", x: 5, y: 0, w: 5, h: 5, want: []string{"big"}},
+		{name: "negative coordinates", x: -16, y: -16, w: 2, h: 2, want: []string{"big"}},
+		{name: "just outside negative corner", x: -20, y: -20, w: 5, h: 5, want: []string{}},
+		{name: "entity in many cells reported once", x: -15, y: -15, w: 30, h: 30, want: []string{"a", "big"}},
+		{name: "crosses cell boundary", x: 24, y: 4, w: 2, h: 2, want: []string{"edge"}},
+		{name: "empty area", x: 50, y: 50, w: 10, h: 10, want: []string{}},
+		{name: "zero size point", x: 1, y: 1, w: 0, h: 0, want: []string{"a", "big"}},
+		{name: "whole world", x: -100, y: -100, w: 300, h: 300, want: []string{"a", "big", "edge", "far"}},
+	}
+
+	grid := newTestGrid()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(grid.QueryRect(tt.x, tt.y, tt.w, tt.h))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpatialGridUpdateAndRemove(t *testing.T) {
+	grid := newTestGrid()
+
+	far := newTestEntity("far", 40, 40, 5, 5)
+	grid.Update(far)
+	if got := names(grid.QueryRect(100, 100, 5, 5)); len(got) != 0 {
+		t.Fatalf("old cell still has %v", got)
+	}
+	if got := names(grid.QueryRect(40, 40, 1, 1)); !reflect.DeepEqual(got, []string{"far"}) {
+		t.Fatalf("new cell has %v, want [far]", got)
+	}
+
+	grid.Remove("big")
+	grid.Remove("missing")
+	if got := names(grid.QueryRect(-15, -15, 30, 30)); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("after remove got %v, want [a]", got)
+	}
+	if len(grid.cells) != 3 {
+		t.Fatalf("%d cells left, want 3 (a, edge, far)", len(grid.cells))
+	}
+}
+
+func TestSpatialGridNearest(t *testing.T) {
+	notBig := func(entity *Entity) bool { return entity.Name != "big" }
+
+	tests := []struct {
+		name      string
+		x, y      int
+		maxRadius int
+		filter    func(*Entity) bool
+		want      string
+	}{
+		{name: "point inside", x: 1, y: 1, maxRadius: 10, filter: notBig, want: "a"},
+		{name: "own cell", x: 22, y: 2, maxRadius: 50, filter: notBig, want: "edge"},
+		{name: "closest in the first ring", x: 19, y: 2, maxRadius: 50, filter: notBig, want: "edge"},
+		{name: "negative coordinates", x: -30, y: -30, maxRadius: 30, want: "big"},
+		{name: "out of radius", x: 60, y: 60, maxRadius: 10, want: ""},
+		{name: "several rings away", x: 60, y: 60, maxRadius: 100, want: "far"},
+		{name: "radius is inclusive", x: 110, y: 105, maxRadius: 5, want: "far"},
+		{name: "filter rejects everything", x: 1, y: 1, maxRadius: 1000, filter: func(*Entity) bool { return false }, want: ""},
+	}
+
+	grid := newTestGrid()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if nearest := grid.Nearest(tt.x, tt.y, tt.maxRadius, tt.filter); nearest != nil {
+				got = nearest.Name
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
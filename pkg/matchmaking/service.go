@@ -0,0 +1,309 @@
+package matchmaking
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StateQueued  = "queued"
+	StateMatched = "matched"
+)
+
+var (
+	ErrAlreadyQueued = errors.New("player is already queued")
+	ErrUnknownMode   = errors.New("unknown mode")
+)
+
+// resultTTL - сколько хранится результат подбора, чтобы игрок успел его
+// забрать
+const resultTTL = 5 * time.Minute
+
+// Config задает режимы и то, как расширяется окно рейтинга со временем
+// ожидания
+type Config struct {
+	// Modes - число игроков в матче для каждого режима
+	Modes map[string]int
+	// BaseWindow - допустимая разница рейтингов сразу после постановки
+	BaseWindow int
+	// WindowGrowth - на сколько окно расширяется за каждую секунду ожидания
+	WindowGrowth int
+	// MaxWindow - предел расширения окна
+	MaxWindow int
+	// Interval - как часто формируются матчи
+	Interval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Modes:        map[string]int{"duel": 2, "squad": 4},
+		BaseWindow:   100,
+		WindowGrowth: 25,
+		MaxWindow:    1000,
+		Interval:     time.Second,
+	}
+}
+
+// Ticket - заявка игрока в очереди
+type Ticket struct {
+	ID         string    `json:"id"`
+	PlayerID   string    `json:"player_id"`
+	Rating     int       `json:"rating"`
+	Mode       string    `json:"mode"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
+
+// Match - группа игроков одного режима с близким рейтингом
+type Match struct {
+	ID      string
+	Mode    string
+	Players []Ticket
+}
+
+// Status - состояние заявки игрока. Room заполнен, когда матч собран.
+type Status struct {
+	State   string   `json:"state"`
+	Ticket  Ticket   `json:"ticket"`
+	Room    string   `json:"room,omitempty"`
+	Players []string `json:"players,omitempty"`
+	Window  int      `json:"window,omitempty"`
+}
+
+// MatchHandler запускает мир для собранного матча и возвращает
+// идентификатор комнаты. При ошибке игроки возвращаются в очередь.
+type MatchHandler = func(match Match) (string, error)
+
+type result struct {
+	status    Status
+	matchedAt time.Time
+}
+
+// Service - очередь подбора игроков
+type Service struct {
+	mut     sync.Mutex
+	config  Config
+	onMatch MatchHandler
+	queue   map[string]Ticket
+	results map[string]result
+	quit    chan struct{}
+	once    sync.Once
+}
+
+func NewService(config Config, onMatch MatchHandler) *Service {
+	defaults := DefaultConfig()
+	if len(config.Modes) == 0 {
+		config.Modes = defaults.Modes
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.MaxWindow < config.BaseWindow {
+		config.MaxWindow = config.BaseWindow
+	}
+
+	return &Service{
+		config:  config,
+		onMatch: onMatch,
+		queue:   make(map[string]Ticket),
+		results: make(map[string]result),
+		quit:    make(chan struct{}),
+	}
+}
+
+// window - допустимая разница рейтингов для заявки, прождавшей waited
+func (s *Service) window(waited time.Duration) int {
+	window := s.config.BaseWindow + int(waited/time.Second)*s.config.WindowGrowth
+	return min(window, s.config.MaxWindow)
+}
+
+// Enqueue ставит игрока в очередь режима mode
+func (s *Service) Enqueue(playerID string, rating int, mode string) (Ticket, error) {
+	if _, ok := s.config.Modes[mode]; !ok {
+		return Ticket{}, ErrUnknownMode
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if _, ok := s.queue[playerID]; ok {
+		return Ticket{}, ErrAlreadyQueued
+	}
+
+	ticket := Ticket{
+		ID:         uuid.New().String(),
+		PlayerID:   playerID,
+		Rating:     rating,
+		Mode:       mode,
+		EnqueuedAt: time.Now(),
+	}
+	s.queue[playerID] = ticket
+	delete(s.results, playerID)
+	return ticket, nil
+}
+
+// Cancel убирает игрока из очереди. Собранный матч отменить нельзя.
+func (s *Service) Cancel(playerID string) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if _, ok := s.queue[playerID]; !ok {
+		return false
+	}
+	delete(s.queue, playerID)
+	return true
+}
+
+// Status возвращает состояние заявки игрока
+func (s *Service) Status(playerID string) (Status, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if ticket, ok := s.queue[playerID]; ok {
+		return Status{
+			State:  StateQueued,
+			Ticket: ticket,
+			Window: s.window(time.Since(ticket.EnqueuedAt)),
+		}, true
+	}
+
+	if r, ok := s.results[playerID]; ok {
+		return r.status, true
+	}
+	return Status{}, false
+}
+
+// formMatches собирает матчи из очереди. Первыми обслуживаются самые
+// долго ждущие заявки: к каждой подбираются ближайшие по рейтингу игроки
+// того же режима в пределах ее окна. Вызывается под блокировкой.
+func (s *Service) formMatches(now time.Time) []Match {
+	byMode := make(map[string][]Ticket)
+	for _, ticket := range s.queue {
+		byMode[ticket.Mode] = append(byMode[ticket.Mode], ticket)
+	}
+
+	var matches []Match
+	for mode, tickets := range byMode {
+		size := s.config.Modes[mode]
+		sort.Slice(tickets, func(i, j int) bool {
+			return tickets[i].EnqueuedAt.Before(tickets[j].EnqueuedAt)
+		})
+
+		taken := make(map[string]bool)
+		for _, anchor := range tickets {
+			if taken[anchor.PlayerID] {
+				continue
+			}
+
+			window := s.window(now.Sub(anchor.EnqueuedAt))
+			var candidates []Ticket
+			for _, other := range tickets {
+				if other.PlayerID == anchor.PlayerID || taken[other.PlayerID] {
+					continue
+				}
+				if abs(other.Rating-anchor.Rating) <= window {
+					candidates = append(candidates, other)
+				}
+			}
+			if len(candidates) < size-1 {
+				continue
+			}
+
+			sort.Slice(candidates, func(i, j int) bool {
+				return abs(candidates[i].Rating-anchor.Rating) < abs(candidates[j].Rating-anchor.Rating)
+			})
+
+			match := Match{
+				ID:      uuid.New().String(),
+				Mode:    mode,
+				Players: append([]Ticket{anchor}, candidates[:size-1]...),
+			}
+			for _, ticket := range match.Players {
+				taken[ticket.PlayerID] = true
+				delete(s.queue, ticket.PlayerID)
+			}
+			matches = append(matches, match)
+		}
+	}
+
+	return matches
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func (s *Service) step(now time.Time) {
+	s.mut.Lock()
+	for playerID, r := range s.results {
+		if now.Sub(r.matchedAt) > resultTTL {
+			delete(s.results, playerID)
+		}
+	}
+	matches := s.formMatches(now)
+	s.mut.Unlock()
+
+	for _, match := range matches {
+		roomID, err := s.onMatch(match)
+
+		s.mut.Lock()
+		if err != nil {
+			log.Printf("Match %s start failed, requeue players: %v", match.ID, err)
+			for _, ticket := range match.Players {
+				if _, ok := s.queue[ticket.PlayerID]; !ok {
+					s.queue[ticket.PlayerID] = ticket
+				}
+			}
+			s.mut.Unlock()
+			continue
+		}
+
+		players := make([]string, 0, len(match.Players))
+		for _, ticket := range match.Players {
+			players = append(players, ticket.PlayerID)
+		}
+		for _, ticket := range match.Players {
+			s.results[ticket.PlayerID] = result{
+				status: Status{
+					State:   StateMatched,
+					Ticket:  ticket,
+					Room:    roomID,
+					Players: players,
+				},
+				matchedAt: now,
+			}
+		}
+		s.mut.Unlock()
+	}
+}
+
+// Start запускает периодический подбор матчей
+func (s *Service) Start() {
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				s.step(now)
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop останавливает подбор
+func (s *Service) Stop() {
+	s.once.Do(func() {
+		close(s.quit)
+	})
+}
@@ -0,0 +1,100 @@
+package network
+
+import (
+	"game_web_server/pkg/entities"
+	"game_web_server/pkg/protocol"
+)
+
+// Базовое состояние клиента - последнее состояние каждой видимой ему
+// сущности, которое поставлено ему в очередь. WebSocket доставляет
+// сообщения по порядку и без потерь, а при переполнении очереди соединение
+// закрывается, поэтому отправленное состояние считается подтвержденным.
+// После переподключения клиент получает новый снапшот и базовое состояние
+// строится заново. Методы вызываются только из горутины рассылки.
+
+// BuildSnapshot сериализует видимые клиенту сущности целиком и делает их
+// базовым состоянием клиента
+func (c *Client) BuildSnapshot(tick uint64, visible map[string]entities.Entity, ackSeq uint32) []byte {
+	c.baseline = make(map[string]entities.Entity, len(visible))
+	states := make([]entities.Entity, 0, len(visible))
+	for name, state := range visible {
+		c.baseline[name] = state
+		states = append(states, state)
+	}
+	c.ackSent = ackSeq
+	c.changed = false
+
+	return protocol.BuildWorldSnapshot(tick, states, ackSeq)
+}
+
+// BuildDelta сериализует разницу между базовым состоянием клиента и
+// текущими видимыми ему сущностями и обновляет базовое состояние:
+// вошедшие в зону видимости сущности идут целиком, изменившиеся - дельтой,
+// вышедшие и удаленные - списками имен. На первом тике без изменений
+// после тика с изменениями отправляется пустая дельта: по ней клиент
+// видит, что сущности остановились, и перестает их экстраполировать.
+// Возвращает nil, если клиенту нечего отправлять: состояние не изменилось
+// и новых вводов не обработано.
+func (c *Client) BuildDelta(tick uint64, updates []entities.EntityUpdate, visible map[string]entities.Entity, ackSeq uint32) []byte {
+	despawned := make(map[string]bool)
+	for _, update := range updates {
+		if update.Type == entities.UpdateDespawn {
+			despawned[update.Name] = true
+		}
+	}
+
+	var states []entities.Entity
+	var deltas []protocol.Delta
+	var removed, left []string
+
+	for name, state := range visible {
+		prev, known := c.baseline[name]
+		c.baseline[name] = state
+
+		if !known {
+			states = append(states, state)
+			continue
+		}
+
+		if mask := protocol.Diff(prev, state); mask != 0 {
+			deltas = append(deltas, protocol.Delta{Mask: mask, State: state})
+		}
+	}
+
+	for name := range c.baseline {
+		if _, ok := visible[name]; ok {
+			continue
+		}
+
+		if despawned[name] {
+			removed = append(removed, name)
+		} else {
+			left = append(left, name)
+		}
+		delete(c.baseline, name)
+	}
+
+	if len(states) == 0 && len(deltas) == 0 && len(removed) == 0 && len(left) == 0 {
+		if c.changed {
+			c.changed = false
+			c.ackSent = ackSeq
+			return protocol.BuildWorldDelta(tick, nil, nil, nil, nil, ackSeq)
+		}
+		if ackSeq == c.ackSent {
+			return nil
+		}
+		c.ackSent = ackSeq
+		return protocol.BuildAck(ackSeq, tick)
+	}
+	c.ackSent = ackSeq
+	c.changed = true
+
+	return protocol.BuildWorldDelta(tick, states, deltas, removed, left, ackSeq)
+}
+
+// NeedsUpdate сообщает, что клиенту нужно сообщение и на тике без
+// изменений мира: ввод ackSeq еще не подтвержден или прошлая дельта несла
+// изменения и клиент еще не знает об остановке
+func (c *Client) NeedsUpdate(ackSeq uint32) bool {
+	return ackSeq != c.ackSent || c.changed
+}
@@ -0,0 +1,162 @@
+package network
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"game_web_server/pkg/entities"
+
+	"github.com/fasthttp/websocket"
+)
+
+// sendBufferSize - сколько исходящих сообщений может ждать отправки.
+// Клиент, который не успевает их забирать, отключается.
+const sendBufferSize = 256
+
+// closeWriteTimeout - сколько ждать отправки close фрейма
+const closeWriteTimeout = time.Second
+
+// frame - исходящий WebSocket кадр
+type frame struct {
+	kind int
+	data []byte
+}
+
+// Client - WebSocket соединение игрока с собственной горутиной записи
+type Client struct {
+	ID string
+	// UserID - пользователь, проверенный при подключении; пустой без
+	// аутентификации
+	UserID string
+	// Synced - получил ли клиент снапшот мира. Пока нет, дельты ему
+	// не отправляются. Меняется только из горутины рассылки.
+	Synced bool
+	// ConnectedAt - когда было установлено соединение
+	ConnectedAt time.Time
+	// Room - комната, к рассылке которой подключено соединение
+	Room        string
+	baseline    map[string]entities.Entity
+	ackSent     uint32
+	changed     bool
+	rtt         atomic.Int64
+	lastMessage atomic.Int64
+	heartbeat   Heartbeat
+	messagesIn  atomic.Uint64
+	messagesOut atomic.Uint64
+	bytesIn     atomic.Uint64
+	bytesOut    atomic.Uint64
+	conn        *websocket.Conn
+	send        chan frame
+	done        chan struct{}
+	once        sync.Once
+}
+
+// NewClient создает клиента для уже установленного соединения
+func NewClient(id string, conn *websocket.Conn) *Client {
+	return &Client{
+		ID:          id,
+		ConnectedAt: time.Now(),
+		conn:        conn,
+		send:        make(chan frame, sendBufferSize),
+		done:        make(chan struct{}),
+	}
+}
+
+// Send ставит сообщение в очередь на отправку, не блокируя вызывающего.
+// При переполненной очереди соединение закрывается, чтобы медленный
+// клиент не тормозил рассылку остальным.
+func (c *Client) Send(data []byte) bool {
+	return c.enqueue(frame{kind: websocket.BinaryMessage, data: data})
+}
+
+// CloseWith отправляет data последним сообщением, за ним close фрейм с
+// code и reason, и закрывает соединение. Сообщения, поставленные в очередь
+// раньше, успевают уйти.
+func (c *Client) CloseWith(data []byte, code int, reason string) {
+	if c.Send(data) {
+		c.CloseGracefully(code, reason)
+	}
+}
+
+// CloseGracefully закрывает соединение close фреймом с code и reason
+// после уже поставленных в очередь сообщений
+func (c *Client) CloseGracefully(code int, reason string) {
+	c.enqueue(frame{kind: websocket.CloseMessage, data: websocket.FormatCloseMessage(code, reason)})
+}
+
+func (c *Client) enqueue(f frame) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.send <- f:
+		return true
+	default:
+		log.Println("Send buffer overflow, closing client:", c.ID)
+		c.Close()
+		return false
+	}
+}
+
+// WritePump отправляет сообщения из очереди, пока клиент не будет закрыт
+func (c *Client) WritePump() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case f := <-c.send:
+			if f.kind == websocket.CloseMessage {
+				c.conn.WriteControl(f.kind, f.data, time.Now().Add(closeWriteTimeout))
+				c.Close()
+				return
+			}
+			if err := c.conn.WriteMessage(f.kind, f.data); err != nil {
+				log.Println("Write error:", err)
+				c.Close()
+				return
+			}
+			c.messagesOut.Add(1)
+			c.bytesOut.Add(uint64(len(f.data)))
+		}
+	}
+}
+
+// Stats возвращает счетчики трафика соединения
+func (c *Client) Stats() Stats {
+	return Stats{
+		MessagesIn:  c.messagesIn.Load(),
+		MessagesOut: c.messagesOut.Load(),
+		BytesIn:     c.bytesIn.Load(),
+		BytesOut:    c.bytesOut.Load(),
+	}
+}
+
+// Info возвращает метаданные соединения
+func (c *Client) Info() ConnInfo {
+	return ConnInfo{
+		PlayerID:    c.ID,
+		UserID:      c.UserID,
+		Room:        c.Room,
+		ConnectedAt: c.ConnectedAt,
+		RTTMillis:   float64(c.RTT()) / float64(time.Millisecond),
+		Stats:       c.Stats(),
+	}
+}
+
+// Done закрывается, когда соединение клиента завершено
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close закрывает соединение; повторные вызовы безопасны
+func (c *Client) Close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
@@ -0,0 +1,121 @@
+package network
+
+import (
+	"encoding/binary"
+	"log"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+// rttSmoothing - вес нового замера в скользящем среднем RTT, как у SRTT
+// в TCP
+const rttSmoothing = 8
+
+// Heartbeat - настройки проверки живости соединения
+type Heartbeat struct {
+	// PingInterval - как часто клиенту отправляется WebSocket ping
+	PingInterval time.Duration
+	// PongTimeout - сколько можно не получать от клиента ничего, даже pong,
+	// прежде чем соединение будет считаться мертвым
+	PongTimeout time.Duration
+	// IdleTimeout - через сколько без сообщений игры соединение
+	// закрывается; 0 - не закрывается
+	IdleTimeout time.Duration
+}
+
+// DefaultHeartbeat - ping раз в 5 секунд, обрыв через 15 секунд тишины,
+// отключение после 10 минут без сообщений игры
+func DefaultHeartbeat() Heartbeat {
+	return Heartbeat{
+		PingInterval: 5 * time.Second,
+		PongTimeout:  15 * time.Second,
+		IdleTimeout:  10 * time.Minute,
+	}
+}
+
+// KeepAlive запускает отправку ping и выставляет дедлайн чтения. Вызывать
+// до первого Read. Ответы pong обновляют RTT и продлевают дедлайн.
+func (c *Client) KeepAlive(heartbeat Heartbeat) {
+	c.heartbeat = heartbeat
+	c.conn.SetReadDeadline(time.Now().Add(heartbeat.PongTimeout))
+	c.conn.SetPongHandler(func(payload string) error {
+		if len(payload) == 8 {
+			sent := int64(binary.BigEndian.Uint64([]byte(payload)))
+			if sample := time.Now().UnixNano() - sent; sample >= 0 {
+				c.updateRTT(time.Duration(sample))
+			}
+		}
+		return c.conn.SetReadDeadline(time.Now().Add(heartbeat.PongTimeout))
+	})
+
+	go c.pingLoop(heartbeat)
+}
+
+func (c *Client) pingLoop(heartbeat Heartbeat) {
+	ticker := time.NewTicker(heartbeat.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-ticker.C:
+			if heartbeat.IdleTimeout > 0 && now.Sub(c.LastMessage()) > heartbeat.IdleTimeout {
+				log.Printf("Player %s idle for %v, closing", c.ID, heartbeat.IdleTimeout)
+				c.CloseGracefully(websocket.CloseNormalClosure, "idle timeout")
+				return
+			}
+
+			payload := binary.BigEndian.AppendUint64(nil, uint64(now.UnixNano()))
+			if err := c.conn.WriteControl(websocket.PingMessage, payload, now.Add(closeWriteTimeout)); err != nil {
+				log.Println("Ping error:", err)
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// Read читает следующее сообщение клиента и, после KeepAlive, продлевает
+// дедлайн чтения. Вызывается только из горутины чтения.
+func (c *Client) Read() ([]byte, error) {
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.lastMessage.Store(now.UnixNano())
+	c.messagesIn.Add(1)
+	c.bytesIn.Add(uint64(len(data)))
+	if c.heartbeat.PongTimeout > 0 {
+		c.conn.SetReadDeadline(now.Add(c.heartbeat.PongTimeout))
+	}
+	return data, nil
+}
+
+func (c *Client) updateRTT(sample time.Duration) {
+	rtt := time.Duration(c.rtt.Load())
+	if rtt == 0 {
+		rtt = sample
+	} else {
+		rtt += (sample - rtt) / rttSmoothing
+	}
+	c.rtt.Store(int64(rtt))
+}
+
+// RTT возвращает сглаженное время туда и обратно по ping/pong; 0, пока
+// не было ни одного замера
+func (c *Client) RTT() time.Duration {
+	return time.Duration(c.rtt.Load())
+}
+
+// LastMessage возвращает время последнего сообщения игры от клиента или
+// время подключения, если сообщений не было
+func (c *Client) LastMessage() time.Time {
+	if at := c.lastMessage.Load(); at != 0 {
+		return time.Unix(0, at)
+	}
+	return c.ConnectedAt
+}
@@ -0,0 +1,33 @@
+package network
+
+import "game_web_server/pkg/entities"
+
+// DefaultViewArea - размер зоны видимости вокруг игрока по умолчанию
+var DefaultViewArea = entities.Size{Width: 1600, Height: 1200}
+
+// VisibleEntities возвращает сущности в прямоугольнике view с центром в
+// сущности игрока. Сам игрок виден всегда. Если у игрока нет сущности или
+// view нулевой, возвращается весь мир.
+func VisibleEntities(em *entities.EntityManager, playerID string, view entities.Size) map[string]entities.Entity {
+	player := em.GetByName(playerID)
+
+	var list []entities.Entity
+	if player == nil || view.Width <= 0 || view.Height <= 0 {
+		list = em.All()
+	} else {
+		corner := entities.Position{
+			X: player.X + player.Width/2 - view.Width/2,
+			Y: player.Y + player.Height/2 - view.Height/2,
+		}
+		list = em.QueryRect(corner, view)
+	}
+
+	visible := make(map[string]entities.Entity, len(list)+1)
+	for _, entity := range list {
+		visible[entity.Name] = entity
+	}
+	if player != nil {
+		visible[player.Name] = *player
+	}
+	return visible
+}
@@ -0,0 +1,100 @@
+package network
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxConnsPerIP - сколько одновременных соединений разрешено с
+// одного адреса
+const DefaultMaxConnsPerIP = 8
+
+// OriginPolicy - список разрешенных Origin для апгрейда WebSocket
+type OriginPolicy struct {
+	any     bool
+	allowed map[string]bool
+}
+
+// NewOriginPolicy создает политику из списка вида "https://game.example".
+// Пустой список или "*" разрешают любой Origin.
+func NewOriginPolicy(origins []string) *OriginPolicy {
+	policy := &OriginPolicy{allowed: make(map[string]bool)}
+	for _, origin := range origins {
+		origin = strings.TrimSpace(origin)
+		switch origin {
+		case "":
+		case "*":
+			policy.any = true
+		default:
+			policy.allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+		}
+	}
+	if len(policy.allowed) == 0 {
+		policy.any = true
+	}
+	return policy
+}
+
+// Allows проверяет заголовок Origin. Запросы без Origin приходят не из
+// браузера (например, от нативного клиента) и пропускаются.
+func (p *OriginPolicy) Allows(origin string) bool {
+	if p.any || origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return false
+	}
+	return p.allowed[strings.ToLower(parsed.Scheme+"://"+parsed.Host)]
+}
+
+// IPLimiter ограничивает число одновременных соединений с одного адреса
+type IPLimiter struct {
+	mut    sync.Mutex
+	max    int
+	counts map[string]int
+}
+
+// NewIPLimiter создает ограничитель; max <= 0 - без ограничения
+func NewIPLimiter(max int) *IPLimiter {
+	return &IPLimiter{
+		max:    max,
+		counts: make(map[string]int),
+	}
+}
+
+// Acquire занимает слот для ip. Возвращает false, если лимит исчерпан.
+// Каждый успешный Acquire должен завершаться Release.
+func (l *IPLimiter) Acquire(ip string) bool {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if l.max > 0 && l.counts[ip] >= l.max {
+		return false
+	}
+	l.counts[ip]++
+	return true
+}
+
+// Full сообщает, что слотов для ip не осталось, ничего не занимая.
+// Годится для раннего отказа; занимать слот все равно нужно через Acquire.
+func (l *IPLimiter) Full(ip string) bool {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	return l.max > 0 && l.counts[ip] >= l.max
+}
+
+// Release освобождает слот, занятый Acquire
+func (l *IPLimiter) Release(ip string) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if l.counts[ip] <= 1 {
+		delete(l.counts, ip)
+		return
+	}
+	l.counts[ip]--
+}
@@ -0,0 +1,184 @@
+package network
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// violationWindow - через сколько времени без нарушений счетчик
+// нарушений сбрасывается
+const violationWindow = 10 * time.Second
+
+// Rate - ограничение token bucket: PerSecond токенов в секунду, не больше
+// Burst накопленных
+type Rate struct {
+	PerSecond float64
+	Burst     int
+}
+
+// RateLimits - лимиты сообщений одного соединения
+type RateLimits struct {
+	// Default применяется ко всем действиям без своего лимита, общий на них
+	Default Rate
+	Actions map[string]Rate
+	// Messages - лимиты остальных сообщений по типу (chat, ping, ack). У
+	// каждого типа своя корзина; тип без лимита получает Default.
+	Messages map[string]Rate
+	// MaxViolations - после скольких отброшенных сообщений (без
+	// паузы дольше violationWindow) соединение закрывается; 0 - не закрывать
+	MaxViolations int
+}
+
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		Default: Rate{PerSecond: 30, Burst: 60},
+		Actions: map[string]Rate{
+			"player_gun": {PerSecond: 5, Burst: 5},
+		},
+		Messages: map[string]Rate{
+			"chat": {PerSecond: 1, Burst: 5},
+			"ping": {PerSecond: 5, Burst: 10},
+			"ack":  {PerSecond: 60, Burst: 120},
+		},
+		MaxViolations: 50,
+	}
+}
+
+// ParseRates разбирает список вида "player_gun=5:5,player_move=30:60"
+// (действие=в_секунду:всплеск)
+func ParseRates(spec string) (map[string]Rate, error) {
+	rates := make(map[string]Rate)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(item, "=")
+		perSecond, burst, okRate := strings.Cut(value, ":")
+		if !ok || !okRate || name == "" {
+			return nil, fmt.Errorf("rate %q: expected action=per_second:burst", item)
+		}
+
+		rate, err := strconv.ParseFloat(perSecond, 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("rate %q: bad per second value", item)
+		}
+		size, err := strconv.Atoi(burst)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("rate %q: bad burst value", item)
+		}
+
+		rates[name] = Rate{PerSecond: rate, Burst: size}
+	}
+	return rates, nil
+}
+
+type tokenBucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate Rate, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		tokens: float64(rate.Burst),
+		last:   now,
+	}
+}
+
+func (b *tokenBucket) take(now time.Time) bool {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	b.tokens = min(float64(b.rate.Burst), b.tokens+elapsed*b.rate.PerSecond)
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// ActionLimiter ограничивает частоту действий одного соединения. Не
+// потокобезопасен: используется только из горутины чтения соединения.
+type ActionLimiter struct {
+	limits        RateLimits
+	buckets       map[string]*tokenBucket
+	fallback      *tokenBucket
+	messages      map[string]*tokenBucket
+	violations    int
+	lastViolation time.Time
+}
+
+func NewActionLimiter(limits RateLimits) *ActionLimiter {
+	return &ActionLimiter{
+		limits:   limits,
+		buckets:  make(map[string]*tokenBucket),
+		messages: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow списывает токен для действия. Если токенов нет, сообщение нужно
+// отбросить, а нарушение засчитывается.
+func (l *ActionLimiter) Allow(action string, now time.Time) bool {
+	bucket, ok := l.buckets[action]
+	if !ok {
+		if rate, configured := l.limits.Actions[action]; configured {
+			bucket = newTokenBucket(rate, now)
+			l.buckets[action] = bucket
+		} else {
+			// неизвестные имена делят одну корзину, иначе ими можно
+			// раздуть память
+			if l.fallback == nil {
+				l.fallback = newTokenBucket(l.limits.Default, now)
+			}
+			bucket = l.fallback
+		}
+	}
+
+	return l.take(bucket, now)
+}
+
+// AllowMessage списывает токен для сообщения, которое не является вводом,
+// по его типу. Типов немного, поэтому корзина у каждого своя, и они не
+// делят ее с вводами.
+func (l *ActionLimiter) AllowMessage(payload string, now time.Time) bool {
+	bucket, ok := l.messages[payload]
+	if !ok {
+		rate, configured := l.limits.Messages[payload]
+		if !configured {
+			rate = l.limits.Default
+		}
+		bucket = newTokenBucket(rate, now)
+		l.messages[payload] = bucket
+	}
+
+	return l.take(bucket, now)
+}
+
+// take списывает токен из bucket и засчитывает нарушение, если его нет
+func (l *ActionLimiter) take(bucket *tokenBucket, now time.Time) bool {
+	if bucket.take(now) {
+		return true
+	}
+
+	if now.Sub(l.lastViolation) > violationWindow {
+		l.violations = 0
+	}
+	l.violations++
+	l.lastViolation = now
+	return false
+}
+
+// Violations возвращает текущее число нарушений
+func (l *ActionLimiter) Violations() int {
+	return l.violations
+}
+
+// Exceeded сообщает, что соединение превысило порог нарушений
+func (l *ActionLimiter) Exceeded() bool {
+	return l.limits.MaxViolations > 0 && l.violations >= l.limits.MaxViolations
+}
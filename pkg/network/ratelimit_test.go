@@ -0,0 +1,157 @@
+package network
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketRefill(t *testing.T) {
+	type take struct {
+		at   time.Duration
+		want bool
+	}
+
+	tests := []struct {
+		name  string
+		rate  Rate
+		takes []take
+	}{
+		{
+			name:  "burst then empty",
+			rate:  Rate{PerSecond: 1, Burst: 3},
+			takes: []take{{0, true}, {0, true}, {0, true}, {0, false}},
+		},
+		{
+			name:  "one token per interval",
+			rate:  Rate{PerSecond: 2, Burst: 1},
+			takes: []take{{0, true}, {100 * time.Millisecond, false}, {500 * time.Millisecond, true}, {900 * time.Millisecond, false}, {1100 * time.Millisecond, true}},
+		},
+		{
+			name:  "partial tokens add up",
+			rate:  Rate{PerSecond: 4, Burst: 1},
+			takes: []take{{0, true}, {125 * time.Millisecond, false}, {250 * time.Millisecond, true}},
+		},
+		{
+			name:  "refill is capped at burst",
+			rate:  Rate{PerSecond: 10, Burst: 2},
+			takes: []take{{0, true}, {0, true}, {time.Hour, true}, {time.Hour, true}, {time.Hour, false}},
+		},
+		{
+			name:  "fractional rate",
+			rate:  Rate{PerSecond: 0.5, Burst: 1},
+			takes: []take{{0, true}, {time.Second, false}, {2 * time.Second, true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Unix(0, 0)
+			bucket := newTokenBucket(tt.rate, start)
+			for i, step := range tt.takes {
+				if got := bucket.take(start.Add(step.at)); got != step.want {
+					t.Fatalf("take %d at %v: got %v, want %v", i, step.at, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestActionLimiter(t *testing.T) {
+	limits := RateLimits{
+		Default:       Rate{PerSecond: 1, Burst: 1},
+		Actions:       map[string]Rate{"player_gun": {PerSecond: 1, Burst: 2}},
+		Messages:      map[string]Rate{"ping": {PerSecond: 1, Burst: 1}},
+		MaxViolations: 2,
+	}
+	start := time.Unix(0, 0)
+
+	tests := []struct {
+		name     string
+		calls    []string
+		at       []time.Duration
+		allowed  []bool
+		exceeded bool
+	}{
+		{
+			name:    "configured action has its own bucket",
+			calls:   []string{"player_gun", "player_gun", "player_move"},
+			at:      []time.Duration{0, 0, 0},
+			allowed: []bool{true, true, true},
+		},
+		{
+			name:    "other messages have their own buckets",
+			calls:   []string{"a", "ping", "ack", "ping"},
+			at:      []time.Duration{0, 0, 0, 0},
+			allowed: []bool{true, true, true, false},
+		},
+		{
+			name:    "unknown actions share the default bucket",
+			calls:   []string{"a", "b"},
+			at:      []time.Duration{0, 0},
+			allowed: []bool{true, false},
+		},
+		{
+			name:     "violations in a row close the connection",
+			calls:    []string{"a", "a", "a"},
+			at:       []time.Duration{0, 0, 0},
+			allowed:  []bool{true, false, false},
+			exceeded: true,
+		},
+		{
+			name:    "violations reset after a quiet window",
+			calls:   []string{"a", "a", "a", "a"},
+			at:      []time.Duration{0, 0, violationWindow + time.Second, violationWindow + time.Second},
+			allowed: []bool{true, false, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewActionLimiter(limits)
+			var allowed []bool
+			for i, action := range tt.calls {
+				at := start.Add(tt.at[i])
+				if action == "ping" || action == "ack" {
+					allowed = append(allowed, limiter.AllowMessage(action, at))
+				} else {
+					allowed = append(allowed, limiter.Allow(action, at))
+				}
+			}
+			if !reflect.DeepEqual(allowed, tt.allowed) {
+				t.Fatalf("got %v, want %v", allowed, tt.allowed)
+			}
+			if limiter.Exceeded() != tt.exceeded {
+				t.Fatalf("exceeded %v after %d violations, want %v", limiter.Exceeded(), limiter.Violations(), tt.exceeded)
+			}
+		})
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    map[string]Rate
+		wantErr bool
+	}{
+		{spec: "", want: map[string]Rate{}},
+		{spec: "player_gun=5:5, chat=0.5:3,", want: map[string]Rate{"player_gun": {5, 5}, "chat": {0.5, 3}}},
+		{spec: "player_gun=5", wantErr: true},
+		{spec: "=5:5", wantErr: true},
+		{spec: "player_gun=0:5", wantErr: true},
+		{spec: "player_gun=5:0", wantErr: true},
+		{spec: "player_gun=x:5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseRates(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
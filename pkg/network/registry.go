@@ -0,0 +1,152 @@
+package network
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats - счетчики трафика соединения
+type Stats struct {
+	MessagesIn  uint64 `json:"messages_in"`
+	MessagesOut uint64 `json:"messages_out"`
+	BytesIn     uint64 `json:"bytes_in"`
+	BytesOut    uint64 `json:"bytes_out"`
+}
+
+// ConnInfo - метаданные соединения для лобби, логов и плагинов
+type ConnInfo struct {
+	PlayerID    string    `json:"player_id"`
+	UserID      string    `json:"user_id,omitempty"`
+	Room        string    `json:"room"`
+	ConnectedAt time.Time `json:"connected_at"`
+	RTTMillis   float64   `json:"rtt_ms"`
+	Stats       Stats     `json:"stats"`
+}
+
+// Registry - потокобезопасный набор соединений по идентификатору игрока.
+// У игрока не больше одного соединения. Callback'и OnConnect и
+// OnDisconnect вызываются вне блокировки в горутине, которая добавила или
+// убрала соединение.
+type Registry struct {
+	mut          sync.RWMutex
+	clients      map[string]*Client
+	onConnect    []func(*Client)
+	onDisconnect []func(*Client)
+}
+
+// NewRegistry создает пустой реестр соединений
+func NewRegistry() *Registry {
+	return &Registry{
+		clients: make(map[string]*Client),
+	}
+}
+
+// OnConnect подписывает callback на добавление соединения
+func (r *Registry) OnConnect(callback func(*Client)) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.onConnect = append(r.onConnect, callback)
+}
+
+// OnDisconnect подписывает callback на удаление соединения, в том числе
+// вытесненного новым соединением того же игрока
+func (r *Registry) OnDisconnect(callback func(*Client)) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.onDisconnect = append(r.onDisconnect, callback)
+}
+
+// Add регистрирует соединение. Если у игрока уже было другое соединение,
+// оно убирается из реестра и возвращается; закрывает его вызывающий.
+func (r *Registry) Add(client *Client) *Client {
+	r.mut.Lock()
+	previous := r.clients[client.ID]
+	r.clients[client.ID] = client
+	onConnect := r.onConnect
+	onDisconnect := r.onDisconnect
+	r.mut.Unlock()
+
+	if previous == client {
+		return nil
+	}
+	if previous != nil {
+		for _, callback := range onDisconnect {
+			callback(previous)
+		}
+	}
+	for _, callback := range onConnect {
+		callback(client)
+	}
+	return previous
+}
+
+// Remove убирает соединение, если оно все еще зарегистрировано для своего
+// игрока. Возвращает false, если его уже вытеснили или убрали.
+func (r *Registry) Remove(client *Client) bool {
+	r.mut.Lock()
+	if r.clients[client.ID] != client {
+		r.mut.Unlock()
+		return false
+	}
+	delete(r.clients, client.ID)
+	onDisconnect := r.onDisconnect
+	r.mut.Unlock()
+
+	for _, callback := range onDisconnect {
+		callback(client)
+	}
+	return true
+}
+
+// RemoveID убирает соединение игрока и возвращает его; nil, если его нет
+func (r *Registry) RemoveID(playerID string) *Client {
+	r.mut.RLock()
+	client := r.clients[playerID]
+	r.mut.RUnlock()
+
+	if client == nil || !r.Remove(client) {
+		return nil
+	}
+	return client
+}
+
+// Get возвращает соединение игрока
+func (r *Registry) Get(playerID string) (*Client, bool) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	client, ok := r.clients[playerID]
+	return client, ok
+}
+
+// Len возвращает число соединений
+func (r *Registry) Len() int {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	return len(r.clients)
+}
+
+// All возвращает снимок текущих соединений
+func (r *Registry) All() []*Client {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	clients := make([]*Client, 0, len(r.clients))
+	for _, client := range r.clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// Range вызывает fn для каждого соединения из снимка, пока fn не вернет
+// false. fn может сама добавлять и убирать соединения.
+func (r *Registry) Range(fn func(*Client) bool) {
+	for _, client := range r.All() {
+		if !fn(client) {
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package protocol
+
+import (
+	"game_web_server/generated"
+	"game_web_server/pkg/entities"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Delta - изменение сущности относительно базового состояния клиента
+type Delta struct {
+	Mask  generated.DeltaField
+	State entities.Entity
+}
+
+// Diff возвращает маску полей, которыми cur отличается от prev
+func Diff(prev, cur entities.Entity) generated.DeltaField {
+	var mask generated.DeltaField
+	if prev.Position != cur.Position {
+		mask |= generated.DeltaFieldPosition
+	}
+	if prev.Size != cur.Size {
+		mask |= generated.DeltaFieldSize
+	}
+	if prev.Image != cur.Image {
+		mask |= generated.DeltaFieldImage
+	}
+	if prev.IsCollision != cur.IsCollision {
+		mask |= generated.DeltaFieldCollision
+	}
+	return mask
+}
+
+// Apply накладывает дельту на базовое состояние сущности
+func Apply(base entities.Entity, delta *generated.EntityDelta) entities.Entity {
+	mask := generated.DeltaField(delta.Mask())
+	if mask&generated.DeltaFieldPosition != 0 {
+		base.X = int(delta.X())
+		base.Y = int(delta.Y())
+	}
+	if mask&generated.DeltaFieldSize != 0 {
+		base.Width = int(delta.Width())
+		base.Height = int(delta.Height())
+	}
+	if mask&generated.DeltaFieldImage != 0 {
+		base.Image = string(delta.Image())
+	}
+	if mask&generated.DeltaFieldCollision != 0 {
+		base.IsCollision = delta.IsCollision()
+	}
+	return base
+}
+
+// buildEntityDelta записывает только поля, отмеченные в маске
+func buildEntityDelta(builder *flatbuffers.Builder, delta Delta) flatbuffers.UOffsetT {
+	name := builder.CreateString(delta.State.Name)
+
+	var image flatbuffers.UOffsetT
+	if delta.Mask&generated.DeltaFieldImage != 0 {
+		image = builder.CreateString(delta.State.Image)
+	}
+
+	generated.EntityDeltaStart(builder)
+	generated.EntityDeltaAddName(builder, name)
+	generated.EntityDeltaAddMask(builder, byte(delta.Mask))
+	if delta.Mask&generated.DeltaFieldPosition != 0 {
+		generated.EntityDeltaAddX(builder, int32(delta.State.X))
+		generated.EntityDeltaAddY(builder, int32(delta.State.Y))
+	}
+	if delta.Mask&generated.DeltaFieldSize != 0 {
+		generated.EntityDeltaAddWidth(builder, int32(delta.State.Width))
+		generated.EntityDeltaAddHeight(builder, int32(delta.State.Height))
+	}
+	if delta.Mask&generated.DeltaFieldImage != 0 {
+		generated.EntityDeltaAddImage(builder, image)
+	}
+	if delta.Mask&generated.DeltaFieldCollision != 0 {
+		generated.EntityDeltaAddIsCollision(builder, delta.State.IsCollision)
+	}
+	return generated.EntityDeltaEnd(builder)
+}
+
+func buildDeltasVector(builder *flatbuffers.Builder, deltas []Delta) flatbuffers.UOffsetT {
+	offsets := make([]flatbuffers.UOffsetT, len(deltas))
+	for i, delta := range deltas {
+		offsets[i] = buildEntityDelta(builder, delta)
+	}
+
+	builder.StartVector(4, len(offsets), 4)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+	return builder.EndVector(len(offsets))
+}
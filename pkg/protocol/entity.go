@@ -0,0 +1,31 @@
+package protocol
+
+import (
+	"game_web_server/generated"
+	"game_web_server/pkg/entities"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// buildEntity записывает таблицу Entity в builder и возвращает её смещение
+func buildEntity(builder *flatbuffers.Builder, entity entities.Entity) flatbuffers.UOffsetT {
+	name := builder.CreateString(entity.Name)
+	image := builder.CreateString(entity.Image)
+	var owner flatbuffers.UOffsetT
+	if entity.Owner != "" {
+		owner = builder.CreateString(entity.Owner)
+	}
+
+	generated.EntityStart(builder)
+	generated.EntityAddName(builder, name)
+	generated.EntityAddImage(builder, image)
+	generated.EntityAddIsCollision(builder, entity.IsCollision)
+	generated.EntityAddX(builder, int32(entity.X))
+	generated.EntityAddY(builder, int32(entity.Y))
+	generated.EntityAddWidth(builder, int32(entity.Width))
+	generated.EntityAddHeight(builder, int32(entity.Height))
+	if owner != 0 {
+		generated.EntityAddOwner(builder, owner)
+	}
+	return generated.EntityEnd(builder)
+}
@@ -0,0 +1,20 @@
+package protocol
+
+import (
+	"game_web_server/generated"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// BuildError собирает сообщение Error с причиной отказа. seq - номер
+// отклоненного ввода или 0, если его не удалось прочитать.
+func BuildError(code generated.ErrorCode, message string, seq uint32) []byte {
+	builder := flatbuffers.NewBuilder(64 + len(message))
+	text := builder.CreateString(message)
+
+	generated.ErrorStart(builder)
+	generated.ErrorAddCode(builder, code)
+	generated.ErrorAddMessage(builder, text)
+	generated.ErrorAddSeq(builder, seq)
+	return finishMessage(builder, generated.PayloadError, generated.ErrorEnd(builder))
+}
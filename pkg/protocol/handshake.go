@@ -0,0 +1,86 @@
+package protocol
+
+import (
+	"fmt"
+
+	"game_web_server/generated"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// MaxSchemaHashLength - предел длины хэша схем в Hello (hex SHA-256)
+const MaxSchemaHashLength = 64
+
+// MaxClientNameLength - предел длины названия клиента в Hello
+const MaxClientNameLength = 64
+
+// BuildHello сериализует приветствие клиента
+func BuildHello(version uint16, schemaHash, client string) []byte {
+	builder := flatbuffers.NewBuilder(192)
+	hashOffset := builder.CreateString(schemaHash)
+	clientOffset := builder.CreateString(client)
+
+	generated.HelloStart(builder)
+	generated.HelloAddVersion(builder, version)
+	generated.HelloAddSchemaHash(builder, hashOffset)
+	generated.HelloAddClient(builder, clientOffset)
+	return finishMessage(builder, generated.PayloadHello, generated.HelloEnd(builder))
+}
+
+// Welcome - ответ сервера на принятый Hello перед сериализацией
+type Welcome struct {
+	SchemaHash   string
+	SessionToken string
+	PlayerID     string
+	Room         string
+}
+
+// BuildWelcome сериализует ответ сервера на принятый Hello
+func BuildWelcome(welcome Welcome) []byte {
+	builder := flatbuffers.NewBuilder(256)
+	hashOffset := builder.CreateString(welcome.SchemaHash)
+	tokenOffset := builder.CreateString(welcome.SessionToken)
+	playerOffset := builder.CreateString(welcome.PlayerID)
+	roomOffset := builder.CreateString(welcome.Room)
+
+	generated.WelcomeStart(builder)
+	generated.WelcomeAddVersion(builder, Version)
+	generated.WelcomeAddSchemaHash(builder, hashOffset)
+	generated.WelcomeAddSessionToken(builder, tokenOffset)
+	generated.WelcomeAddPlayerId(builder, playerOffset)
+	generated.WelcomeAddRoom(builder, roomOffset)
+	return finishMessage(builder, generated.PayloadWelcome, generated.WelcomeEnd(builder))
+}
+
+// CheckHello проверяет, что клиент говорит на текущей версии протокола и
+// собран из тех же схем, что и сервер. Иначе возвращает ValidationError
+// с UpgradeRequired (клиент старее) или UnsupportedVersion (новее).
+func CheckHello(clientVersion uint16, clientHash, serverHash string) error {
+	if err := checkVersion(clientVersion); err != nil {
+		return err
+	}
+	if clientHash != serverHash {
+		return &ValidationError{
+			Code:   generated.ErrorCodeUpgradeRequired,
+			Reason: fmt.Sprintf("client schemas %.12s do not match server schemas %.12s, upgrade the client", clientHash, serverHash),
+		}
+	}
+	return nil
+}
+
+// checkVersion сверяет версию протокола клиента с Version
+func checkVersion(clientVersion uint16) error {
+	switch {
+	case clientVersion > Version:
+		return &ValidationError{
+			Code:   generated.ErrorCodeUnsupportedVersion,
+			Reason: fmt.Sprintf("protocol version %d is newer than the server's %d", clientVersion, Version),
+		}
+	case clientVersion < Version:
+		return &ValidationError{
+			Code:   generated.ErrorCodeUpgradeRequired,
+			Reason: fmt.Sprintf("protocol version %d is no longer supported, upgrade the client to version %d", clientVersion, Version),
+		}
+	}
+	return nil
+}
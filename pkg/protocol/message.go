@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"game_web_server/generated"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Version - текущая версия протокола. С версии 2 клиент начинает обмен
+// с Hello; сообщения других версий сервер не принимает.
+const Version = 2
+
+// finishMessage оборачивает уже записанную в builder таблицу в конверт
+// Message и возвращает готовый буфер
+func finishMessage(builder *flatbuffers.Builder, payloadType generated.Payload, payload flatbuffers.UOffsetT) []byte {
+	generated.MessageStart(builder)
+	generated.MessageAddVersion(builder, Version)
+	generated.MessageAddPayloadType(builder, payloadType)
+	generated.MessageAddPayload(builder, payload)
+	generated.FinishMessageBuffer(builder, generated.MessageEnd(builder))
+	return builder.FinishedBytes()
+}
+
+// Input - действие клиента перед сериализацией
+type Input struct {
+	Action   string
+	Key      string
+	Seq      uint32
+	ViewTick uint64
+	AimX     float32
+	AimY     float32
+}
+
+// BuildInput сериализует действие клиента
+func BuildInput(input Input) []byte {
+	builder := flatbuffers.NewBuilder(128)
+	action := builder.CreateString(input.Action)
+	key := builder.CreateString(input.Key)
+
+	generated.ClientActionStart(builder)
+	generated.ClientActionAddAction(builder, action)
+	generated.ClientActionAddKey(builder, key)
+	generated.ClientActionAddSeq(builder, input.Seq)
+	generated.ClientActionAddViewTick(builder, input.ViewTick)
+	generated.ClientActionAddAimX(builder, input.AimX)
+	generated.ClientActionAddAimY(builder, input.AimY)
+	return finishMessage(builder, generated.PayloadClientAction, generated.ClientActionEnd(builder))
+}
+
+// BuildGameEvent сериализует событие движка; data - подробности в JSON
+func BuildGameEvent(kind, entity, data string, tick uint64) []byte {
+	builder := flatbuffers.NewBuilder(128 + len(data))
+	kindOffset := builder.CreateString(kind)
+	entityOffset := builder.CreateString(entity)
+	dataOffset := builder.CreateString(data)
+
+	generated.GameEventStart(builder)
+	generated.GameEventAddKind(builder, kindOffset)
+	generated.GameEventAddEntity(builder, entityOffset)
+	generated.GameEventAddData(builder, dataOffset)
+	generated.GameEventAddTick(builder, tick)
+	return finishMessage(builder, generated.PayloadGameEvent, generated.GameEventEnd(builder))
+}
+
+// BuildChat сериализует сообщение чата. Клиент оставляет from пустым.
+func BuildChat(from, text string) []byte {
+	builder := flatbuffers.NewBuilder(64 + len(text))
+	fromOffset := builder.CreateString(from)
+	textOffset := builder.CreateString(text)
+
+	generated.ChatStart(builder)
+	generated.ChatAddFrom(builder, fromOffset)
+	generated.ChatAddText(builder, textOffset)
+	return finishMessage(builder, generated.PayloadChat, generated.ChatEnd(builder))
+}
+
+// BuildPing сериализует Ping или, если reply, ответ на него
+func BuildPing(id uint32, timestamp int64, reply bool) []byte {
+	builder := flatbuffers.NewBuilder(64)
+
+	generated.PingStart(builder)
+	generated.PingAddId(builder, id)
+	generated.PingAddTimestamp(builder, timestamp)
+	generated.PingAddReply(builder, reply)
+	return finishMessage(builder, generated.PayloadPing, generated.PingEnd(builder))
+}
+
+// BuildAck подтверждает обработку вводов до seq включительно, когда
+// клиенту больше нечего отправить на этом тике
+func BuildAck(seq uint32, tick uint64) []byte {
+	builder := flatbuffers.NewBuilder(64)
+
+	generated.AckStart(builder)
+	generated.AckAddSeq(builder, seq)
+	generated.AckAddTick(builder, tick)
+	return finishMessage(builder, generated.PayloadAck, generated.AckEnd(builder))
+}
+
+// BuildShutdown сообщает клиенту, что сервер останавливается
+func BuildShutdown(reason string) []byte {
+	builder := flatbuffers.NewBuilder(64 + len(reason))
+	reasonOffset := builder.CreateString(reason)
+
+	generated.ShutdownStart(builder)
+	generated.ShutdownAddReason(builder, reasonOffset)
+	return finishMessage(builder, generated.PayloadShutdown, generated.ShutdownEnd(builder))
+}
+
+// table - таблица FlatBuffers, которую можно поставить на место payload
+type table[T any] interface {
+	*T
+	Init(buf []byte, i flatbuffers.UOffsetT)
+}
+
+// PayloadAs достает из сообщения payload типа T, например
+// PayloadAs[generated.WorldDelta](message). Тип нужно заранее проверить
+// по PayloadType.
+func PayloadAs[T any, P table[T]](message *generated.Message) P {
+	var payload flatbuffers.Table
+	message.Payload(&payload)
+
+	result := P(new(T))
+	result.Init(payload.Bytes, payload.Pos)
+	return result
+}
@@ -0,0 +1,224 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"game_web_server/generated"
+)
+
+const (
+	// MaxClientMessageSize - наибольший допустимый размер сообщения клиента
+	MaxClientMessageSize = 1024
+	// MaxActionNameLength и MaxKeyLength ограничивают строки ClientAction
+	MaxActionNameLength = 64
+	MaxKeyLength        = 32
+	// MaxChatLength - наибольшая длина сообщения чата в байтах
+	MaxChatLength = 256
+)
+
+// ValidationError - причина, по которой сообщение клиента отклонено.
+// Code уходит клиенту в таблице Error.
+type ValidationError struct {
+	Code   generated.ErrorCode
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Code.String() + ": " + e.Reason
+}
+
+func malformed(format string, args ...any) *ValidationError {
+	return &ValidationError{Code: generated.ErrorCodeMalformed, Reason: fmt.Sprintf(format, args...)}
+}
+
+// tableVerifier проверяет, что таблица FlatBuffers и ее поля целиком
+// лежат внутри буфера. Аксессоры сгенерированного кода границы не
+// проверяют и на битом буфере паникуют.
+type tableVerifier struct {
+	buf        []byte
+	pos        int
+	vtable     int
+	vtableSize int
+	objectSize int
+}
+
+func (v *tableVerifier) inBounds(pos, size int) bool {
+	return pos >= 0 && size >= 0 && pos <= len(v.buf)-size
+}
+
+func (v *tableVerifier) uint32At(pos int) uint32 {
+	return binary.LittleEndian.Uint32(v.buf[pos:])
+}
+
+func (v *tableVerifier) uint16At(pos int) int {
+	return int(binary.LittleEndian.Uint16(v.buf[pos:]))
+}
+
+// verifyRoot проверяет корневую таблицу буфера
+func verifyRoot(buf []byte) (*tableVerifier, error) {
+	if len(buf) < 4 {
+		return nil, malformed("buffer is too short")
+	}
+	return verifyTableAt(buf, int(binary.LittleEndian.Uint32(buf)))
+}
+
+// verifyTableAt проверяет таблицу, начинающуюся в pos
+func verifyTableAt(buf []byte, pos int) (*tableVerifier, error) {
+	v := &tableVerifier{buf: buf, pos: pos}
+	if !v.inBounds(v.pos, 4) {
+		return nil, malformed("table offset out of bounds")
+	}
+
+	v.vtable = v.pos - int(int32(v.uint32At(v.pos)))
+	if !v.inBounds(v.vtable, 4) {
+		return nil, malformed("vtable out of bounds")
+	}
+
+	v.vtableSize = v.uint16At(v.vtable)
+	v.objectSize = v.uint16At(v.vtable + 2)
+	if v.vtableSize < 4 || v.vtableSize%2 != 0 || !v.inBounds(v.vtable, v.vtableSize) {
+		return nil, malformed("bad vtable size")
+	}
+	if v.objectSize < 4 || !v.inBounds(v.pos, v.objectSize) {
+		return nil, malformed("table out of bounds")
+	}
+
+	return v, nil
+}
+
+// field возвращает позицию поля slot или -1, если поле не задано.
+// Поле размера size должно помещаться внутри таблицы.
+func (v *tableVerifier) field(slot, size int) (int, error) {
+	entry := 4 + 2*slot
+	if entry+2 > v.vtableSize {
+		return -1, nil
+	}
+
+	offset := v.uint16At(v.vtable + entry)
+	if offset == 0 {
+		return -1, nil
+	}
+	if offset+size > v.objectSize {
+		return -1, malformed("field %d out of table", slot)
+	}
+	return v.pos + offset, nil
+}
+
+// scalar проверяет скалярное поле размера size
+func (v *tableVerifier) scalar(slot, size int) error {
+	_, err := v.field(slot, size)
+	return err
+}
+
+// table проверяет поле-таблицу и возвращает проверку для нее или nil,
+// если поле не задано
+func (v *tableVerifier) table(slot int) (*tableVerifier, error) {
+	pos, err := v.field(slot, 4)
+	if err != nil || pos < 0 {
+		return nil, err
+	}
+	return verifyTableAt(v.buf, pos+int(v.uint32At(pos)))
+}
+
+// string проверяет строковое поле: смещение, длину, завершающий ноль и
+// ограничение maxLength
+func (v *tableVerifier) string(slot, maxLength int) error {
+	pos, err := v.field(slot, 4)
+	if err != nil || pos < 0 {
+		return err
+	}
+
+	start := pos + int(v.uint32At(pos))
+	if !v.inBounds(start, 4) {
+		return malformed("string %d out of bounds", slot)
+	}
+
+	length := int(v.uint32At(start))
+	if length > maxLength {
+		return malformed("string %d is longer than %d bytes", slot, maxLength)
+	}
+	if !v.inBounds(start+4, length+1) || v.buf[start+4+length] != 0 {
+		return malformed("string %d is truncated", slot)
+	}
+	return nil
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyClientMessage проверяет конверт Message от клиента и его payload
+// до обращения к полям. Клиент может присылать только Hello,
+// ClientAction, Chat, Ping и Ack. После успешной проверки аксессоры
+// generated безопасны.
+func VerifyClientMessage(buf []byte) (*generated.Message, error) {
+	if len(buf) > MaxClientMessageSize {
+		return nil, &ValidationError{
+			Code:   generated.ErrorCodeTooLarge,
+			Reason: fmt.Sprintf("message is %d bytes, limit is %d", len(buf), MaxClientMessageSize),
+		}
+	}
+	if len(buf) < 8 || !generated.MessageBufferHasIdentifier(buf) {
+		return nil, malformed("not a Message buffer")
+	}
+
+	v, err := verifyRoot(buf)
+	if err != nil {
+		return nil, err
+	}
+	if err := firstError(v.scalar(0, 2), v.scalar(1, 1)); err != nil {
+		return nil, err
+	}
+	payload, err := v.table(2)
+	if err != nil {
+		return nil, err
+	}
+
+	message := generated.GetRootAsMessage(buf, 0)
+	if err := checkVersion(message.Version()); err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		return nil, malformed("message has no payload")
+	}
+
+	switch message.PayloadType() {
+	case generated.PayloadClientAction:
+		err = firstError(
+			payload.string(0, MaxActionNameLength),
+			payload.string(1, MaxKeyLength),
+			payload.scalar(2, 4), // seq
+			payload.scalar(3, 8), // view_tick
+			payload.scalar(4, 4), // aim_x
+			payload.scalar(5, 4), // aim_y
+		)
+	case generated.PayloadChat:
+		err = firstError(payload.string(0, MaxChatLength), payload.string(1, MaxChatLength))
+	case generated.PayloadPing:
+		err = firstError(payload.scalar(0, 4), payload.scalar(1, 8), payload.scalar(2, 1))
+	case generated.PayloadAck:
+		err = firstError(payload.scalar(0, 4), payload.scalar(1, 8))
+	case generated.PayloadHello:
+		err = firstError(
+			payload.scalar(0, 2), // version
+			payload.string(1, MaxSchemaHashLength),
+			payload.string(2, MaxClientNameLength),
+		)
+	default:
+		err = &ValidationError{
+			Code:   generated.ErrorCodeUnexpectedMessage,
+			Reason: "clients cannot send " + message.PayloadType().String(),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
@@ -0,0 +1,150 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"game_web_server/generated"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// pingWithVersion собирает Ping с версией конверта version
+func pingWithVersion(version uint16) []byte {
+	builder := flatbuffers.NewBuilder(64)
+	generated.PingStart(builder)
+	generated.PingAddId(builder, 1)
+	ping := generated.PingEnd(builder)
+
+	generated.MessageStart(builder)
+	generated.MessageAddVersion(builder, version)
+	generated.MessageAddPayloadType(builder, generated.PayloadPing)
+	generated.MessageAddPayload(builder, ping)
+	generated.FinishMessageBuffer(builder, generated.MessageEnd(builder))
+	return builder.FinishedBytes()
+}
+
+// patched возвращает копию buf, измененную patch
+func patched(buf []byte, patch func(buf []byte)) []byte {
+	result := bytes.Clone(buf)
+	patch(result)
+	return result
+}
+
+// stringAt возвращает позицию длины строки s в buf
+func stringAt(buf []byte, s string) int {
+	return bytes.Index(buf, []byte(s)) - 4
+}
+
+func TestVerifyClientMessage(t *testing.T) {
+	input := BuildInput(Input{Action: "player_move", Key: "W", Seq: 7})
+
+	tests := []struct {
+		name string
+		buf  []byte
+		code generated.ErrorCode
+		ok   bool
+	}{
+		{name: "input", buf: input, ok: true},
+		{name: "hello", buf: BuildHello(Version, "hash", "test"), ok: true},
+		{name: "chat", buf: BuildChat("", "hi"), ok: true},
+		{name: "ping", buf: BuildPing(1, 2, false), ok: true},
+		{name: "ack", buf: BuildAck(1, 2), ok: true},
+		{name: "empty", buf: nil, code: generated.ErrorCodeMalformed},
+		{name: "shorter than header", buf: input[:6], code: generated.ErrorCodeMalformed},
+		{name: "wrong identifier", buf: patched(input, func(buf []byte) { copy(buf[4:8], "XXXX") }), code: generated.ErrorCodeMalformed},
+		{name: "too large", buf: make([]byte, MaxClientMessageSize+1), code: generated.ErrorCodeTooLarge},
+		{
+			name: "root offset past the end",
+			buf: patched(input, func(buf []byte) {
+				binary.LittleEndian.PutUint32(buf, uint32(len(buf)))
+			}),
+			code: generated.ErrorCodeMalformed,
+		},
+		{
+			name: "vtable out of bounds",
+			buf: patched(input, func(buf []byte) {
+				root := binary.LittleEndian.Uint32(buf)
+				binary.LittleEndian.PutUint32(buf[root:], 0x7fffffff)
+			}),
+			code: generated.ErrorCodeMalformed,
+		},
+		{
+			name: "string length past the end",
+			buf: patched(input, func(buf []byte) {
+				binary.LittleEndian.PutUint32(buf[stringAt(buf, "player_move"):], MaxActionNameLength)
+			}),
+			code: generated.ErrorCodeMalformed,
+		},
+		{
+			name: "string without terminator",
+			buf: patched(input, func(buf []byte) {
+				buf[stringAt(buf, "player_move")+4+len("player_move")] = 'x'
+			}),
+			code: generated.ErrorCodeMalformed,
+		},
+		{name: "action name too long", buf: BuildInput(Input{Action: strings.Repeat("a", MaxActionNameLength+1)}), code: generated.ErrorCodeMalformed},
+		{name: "chat too long", buf: BuildChat("", strings.Repeat("a", MaxChatLength+1)), code: generated.ErrorCodeMalformed},
+		{name: "server payload", buf: BuildWorldDelta(1, nil, nil, nil, nil, 0), code: generated.ErrorCodeUnexpectedMessage},
+		{name: "shutdown from client", buf: BuildShutdown("bye"), code: generated.ErrorCodeUnexpectedMessage},
+		{name: "newer version", buf: pingWithVersion(Version + 1), code: generated.ErrorCodeUnsupportedVersion},
+		{name: "older version", buf: pingWithVersion(Version - 1), code: generated.ErrorCodeUpgradeRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := VerifyClientMessage(tt.buf)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if message == nil {
+					t.Fatal("no message")
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("got %v, want ValidationError %v", err, tt.code)
+			}
+			if validationErr.Code != tt.code {
+				t.Fatalf("got code %v, want %v (%v)", validationErr.Code, tt.code, err)
+			}
+		})
+	}
+}
+
+// Обрезанный буфер отклоняется, а если обрезано только выравнивание в
+// конце - читается без паники в сгенерированных аксессорах
+func TestVerifyClientMessageTruncated(t *testing.T) {
+	buffers := [][]byte{
+		BuildInput(Input{Action: "player_gun", Key: "F", Seq: 3, ViewTick: 10, AimX: 1}),
+		BuildHello(Version, "hash", "test"),
+		BuildChat("", "hello"),
+	}
+
+	for _, full := range buffers {
+		for size := 0; size < len(full); size++ {
+			message, err := VerifyClientMessage(bytes.Clone(full[:size]))
+			if err != nil {
+				continue
+			}
+
+			switch message.PayloadType() {
+			case generated.PayloadClientAction:
+				action := PayloadAs[generated.ClientAction](message)
+				_, _ = action.Action(), action.Key()
+			case generated.PayloadHello:
+				hello := PayloadAs[generated.Hello](message)
+				_, _ = hello.SchemaHash(), hello.Client()
+			case generated.PayloadChat:
+				chat := PayloadAs[generated.Chat](message)
+				_, _ = chat.From(), chat.Text()
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"game_web_server/generated"
+	"game_web_server/pkg/entities"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+func buildEntitiesVector(builder *flatbuffers.Builder, states []entities.Entity) flatbuffers.UOffsetT {
+	offsets := make([]flatbuffers.UOffsetT, len(states))
+	for i, state := range states {
+		offsets[i] = buildEntity(builder, state)
+	}
+
+	builder.StartVector(4, len(offsets), 4)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+	return builder.EndVector(len(offsets))
+}
+
+func buildStringsVector(builder *flatbuffers.Builder, values []string) flatbuffers.UOffsetT {
+	offsets := make([]flatbuffers.UOffsetT, len(values))
+	for i, value := range values {
+		offsets[i] = builder.CreateString(value)
+	}
+
+	builder.StartVector(4, len(offsets), 4)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+	return builder.EndVector(len(offsets))
+}
+
+// BuildWorldDelta сериализует изменения сущностей за один тик: полные
+// состояния сущностей, которых у клиента еще нет, сжатые дельты для
+// известных ему сущностей, имена удаленных и вышедших из зоны видимости,
+// а также номер последнего обработанного ввода клиента
+func BuildWorldDelta(tick uint64, states []entities.Entity, deltas []Delta, removed, left []string, ackSeq uint32) []byte {
+	builder := flatbuffers.NewBuilder(1024)
+
+	// пустые векторы не пишем, чтобы не раздувать маленькие дельты
+	var entitiesVector, deltasVector, removedVector, leftVector flatbuffers.UOffsetT
+	if len(states) > 0 {
+		entitiesVector = buildEntitiesVector(builder, states)
+	}
+	if len(deltas) > 0 {
+		deltasVector = buildDeltasVector(builder, deltas)
+	}
+	if len(removed) > 0 {
+		removedVector = buildStringsVector(builder, removed)
+	}
+	if len(left) > 0 {
+		leftVector = buildStringsVector(builder, left)
+	}
+
+	generated.WorldDeltaStart(builder)
+	generated.WorldDeltaAddTick(builder, tick)
+	generated.WorldDeltaAddAckSeq(builder, ackSeq)
+	if len(states) > 0 {
+		generated.WorldDeltaAddEntities(builder, entitiesVector)
+	}
+	if len(removed) > 0 {
+		generated.WorldDeltaAddRemoved(builder, removedVector)
+	}
+	if len(deltas) > 0 {
+		generated.WorldDeltaAddDeltas(builder, deltasVector)
+	}
+	if len(left) > 0 {
+		generated.WorldDeltaAddLeft(builder, leftVector)
+	}
+	return finishMessage(builder, generated.PayloadWorldDelta, generated.WorldDeltaEnd(builder))
+}
+
+// BuildWorldSnapshot сериализует полное состояние мира, которое клиент
+// получает при подключении перед первой дельтой
+func BuildWorldSnapshot(tick uint64, states []entities.Entity, ackSeq uint32) []byte {
+	builder := flatbuffers.NewBuilder(4096)
+
+	entitiesVector := buildEntitiesVector(builder, states)
+
+	generated.WorldSnapshotStart(builder)
+	generated.WorldSnapshotAddTick(builder, tick)
+	generated.WorldSnapshotAddAckSeq(builder, ackSeq)
+	generated.WorldSnapshotAddEntities(builder, entitiesVector)
+	return finishMessage(builder, generated.PayloadWorldSnapshot, generated.WorldSnapshotEnd(builder))
+}
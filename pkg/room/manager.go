@@ -0,0 +1,346 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"game_web_server/pkg/core"
+	"game_web_server/pkg/entities"
+	"game_web_server/pkg/network"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRoom - комната, в которую попадают игроки без явного выбора.
+// Она не удаляется, даже когда пустеет.
+const DefaultRoom = "main"
+
+// MaxIDLength - предельная длина идентификатора комнаты
+const MaxIDLength = 64
+
+// DefaultMaxRooms - сколько комнат может существовать одновременно
+const DefaultMaxRooms = 64
+
+var (
+	ErrRoomExists   = errors.New("room already exists")
+	ErrRoomNotFound = errors.New("room not found")
+	ErrTooManyRooms = errors.New("too many rooms")
+	ErrRoomReserved = errors.New("room is reserved for other players")
+	ErrBadRoomID    = errors.New("room id must be 1-64 letters, digits, '-' or '_'")
+	ErrShutdown     = errors.New("server is shutting down")
+)
+
+// EngineFactory создает и настраивает движок для новой комнаты
+// (шаблон игрока, плагины). Запускает движок менеджер.
+type EngineFactory = func(roomID string) (*core.Engine, error)
+
+// Manager создает комнаты, переводит игроков между ними и удаляет
+// опустевшие комнаты
+type Manager struct {
+	mut      sync.Mutex
+	factory  EngineFactory
+	viewArea entities.Size
+	maxRooms int
+	rooms    map[string]*Room
+	players  map[string]*Room
+	closed   bool
+}
+
+// NewManager создает менеджер комнат. viewArea - зона видимости игрока
+// для рассылки изменений, maxRooms - сколько комнат может существовать
+// одновременно.
+func NewManager(factory EngineFactory, viewArea entities.Size, maxRooms int) *Manager {
+	return &Manager{
+		factory:  factory,
+		viewArea: viewArea,
+		maxRooms: maxRooms,
+		rooms:    make(map[string]*Room),
+		players:  make(map[string]*Room),
+	}
+}
+
+// create вызывается под блокировкой менеджера
+func (m *Manager) create(id string, players []string) (*Room, error) {
+	if m.closed {
+		return nil, ErrShutdown
+	}
+	if len(m.rooms) >= m.maxRooms {
+		return nil, ErrTooManyRooms
+	}
+
+	engine, err := m.factory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newRoom(id, engine, m.viewArea)
+	if len(players) > 0 {
+		r.reserved = make(map[string]bool, len(players))
+		for _, playerID := range players {
+			r.reserved[playerID] = true
+		}
+	}
+	m.rooms[id] = r
+	engine.Start()
+	return r, nil
+}
+
+// Create создает комнату с заданным идентификатором или, если он пустой,
+// со случайным. Если переданы players, войти в комнату смогут только они.
+func (m *Manager) Create(id string, players ...string) (*Room, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if id == "" {
+		id = uuid.New().String()
+	}
+	if !validID(id) {
+		return nil, ErrBadRoomID
+	}
+	if _, ok := m.rooms[id]; ok {
+		return nil, ErrRoomExists
+	}
+
+	return m.create(id, players)
+}
+
+// validID пропускает короткие идентификаторы из латиницы, цифр, "-" и "_"
+func validID(id string) bool {
+	if len(id) > MaxIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Get возвращает комнату по идентификатору
+func (m *Manager) Get(id string) (*Room, bool) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	r, ok := m.rooms[id]
+	return r, ok
+}
+
+// List возвращает описания всех комнат, отсортированные по идентификатору
+func (m *Manager) List() []Info {
+	rooms := m.all()
+
+	result := make([]Info, 0, len(rooms))
+	for _, r := range rooms {
+		result = append(result, r.Info())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func (m *Manager) all() []*Room {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		rooms = append(rooms, r)
+	}
+	return rooms
+}
+
+// Online возвращает игроков с открытым соединением во всех комнатах,
+// отсортированных по комнате и идентификатору
+func (m *Manager) Online() []Presence {
+	result := []Presence{}
+	for _, r := range m.all() {
+		result = append(result, r.Online()...)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Room != result[j].Room {
+			return result[i].Room < result[j].Room
+		}
+		return result[i].PlayerID < result[j].PlayerID
+	})
+	return result
+}
+
+// Presence возвращает состояние игрока в его текущей комнате
+func (m *Manager) Presence(playerID string) (Presence, bool) {
+	r, ok := m.RoomOf(playerID)
+	if !ok {
+		return Presence{}, false
+	}
+	return r.Presence(playerID)
+}
+
+// RoomOf возвращает комнату, в которой находится игрок
+func (m *Manager) RoomOf(playerID string) (*Room, bool) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	r, ok := m.players[playerID]
+	return r, ok
+}
+
+// Join переводит игрока в существующую комнату id. Если игрок был в
+// другой комнате, он из нее выходит; если он уже в этой
+// комнате (вернулся по сессии), его сущность сохраняется. owner -
+// пользователь, которому принадлежит сущность игрока.
+func (m *Manager) Join(id, playerID, owner string) (*Room, error) {
+	if id == "" {
+		id = DefaultRoom
+	}
+
+	m.mut.Lock()
+	if m.closed {
+		m.mut.Unlock()
+		return nil, ErrShutdown
+	}
+	current := m.players[playerID]
+	if current != nil && current.ID == id {
+		m.mut.Unlock()
+		current.enter(playerID, owner)
+		return current, nil
+	}
+
+	target, ok := m.rooms[id]
+	if !ok {
+		m.mut.Unlock()
+		return nil, ErrRoomNotFound
+	}
+	if !target.Admits(playerID) {
+		m.mut.Unlock()
+		return nil, ErrRoomReserved
+	}
+	m.players[playerID] = target
+	m.mut.Unlock()
+
+	if current != nil {
+		m.exit(current, playerID)
+	}
+
+	target.enter(playerID, owner)
+	return target, nil
+}
+
+// Leave выводит игрока из его комнаты и удаляет его сущность
+func (m *Manager) Leave(playerID string) {
+	m.mut.Lock()
+	current, ok := m.players[playerID]
+	if ok {
+		delete(m.players, playerID)
+	}
+	m.mut.Unlock()
+
+	if ok {
+		m.exit(current, playerID)
+	}
+}
+
+// exit выводит игрока из комнаты и останавливает ее, если она опустела
+func (m *Manager) exit(r *Room, playerID string) {
+	if r.exit(playerID) > 0 || r.ID == DefaultRoom {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	// пока мы выходили, в комнату мог кто-то зайти
+	for _, other := range m.players {
+		if other == r {
+			return
+		}
+	}
+	if m.rooms[r.ID] == r {
+		delete(m.rooms, r.ID)
+	}
+	r.Engine.Stop()
+}
+
+// RemoveIfEmpty останавливает комнату, если в ней нет игроков. Нужен для
+// комнат, созданных заранее (например, под матч), в которые так никто и
+// не зашел.
+func (m *Manager) RemoveIfEmpty(id string) bool {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	r, ok := m.rooms[id]
+	if !ok || id == DefaultRoom {
+		return false
+	}
+	for _, current := range m.players {
+		if current == r {
+			return false
+		}
+	}
+
+	delete(m.rooms, id)
+	r.Engine.Stop()
+	return true
+}
+
+// Remove останавливает комнату и выводит из нее всех игроков
+func (m *Manager) Remove(id string) error {
+	m.mut.Lock()
+	r, ok := m.rooms[id]
+	if !ok {
+		m.mut.Unlock()
+		return ErrRoomNotFound
+	}
+	delete(m.rooms, id)
+
+	var members []string
+	for playerID, current := range m.players {
+		if current == r {
+			members = append(members, playerID)
+			delete(m.players, playerID)
+		}
+	}
+	m.mut.Unlock()
+
+	for _, playerID := range members {
+		r.exit(playerID)
+	}
+	r.Engine.Stop()
+	return nil
+}
+
+// Shutdown останавливает все комнаты при остановке сервера: игроки
+// получают reason и отключаются, движки останавливаются (плагины получают
+// Stop), а мир каждой комнаты сохраняется в saveDir. Новые комнаты после
+// этого не создаются. Если ctx истечет раньше, чем клиенты отключатся,
+// комнаты останавливаются без ожидания.
+func (m *Manager) Shutdown(ctx context.Context, reason, saveDir string) error {
+	m.mut.Lock()
+	m.closed = true
+	m.mut.Unlock()
+
+	rooms := m.all()
+
+	var clients []*network.Client
+	for _, r := range rooms {
+		clients = append(clients, r.Shutdown(reason)...)
+	}
+	for _, client := range clients {
+		select {
+		case <-client.Done():
+		case <-ctx.Done():
+		}
+	}
+
+	var errs []error
+	for _, r := range rooms {
+		r.Engine.Stop()
+		if err := r.Save(saveDir); err != nil {
+			errs = append(errs, fmt.Errorf("save room %s: %w", r.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
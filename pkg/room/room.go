@@ -0,0 +1,272 @@
+package room
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"game_web_server/pkg/core"
+	"game_web_server/pkg/entities"
+	"game_web_server/pkg/network"
+	"game_web_server/pkg/protocol"
+
+	"github.com/fasthttp/websocket"
+)
+
+// Room - изолированный мир со своим движком, сущностями и игровым циклом
+type Room struct {
+	ID        string
+	Engine    *core.Engine
+	CreatedAt time.Time
+
+	mut      sync.Mutex
+	viewArea entities.Size
+	members  map[string]bool
+	clients  *network.Registry
+	// reserved - игроки, которым разрешен вход; nil - комната открыта
+	reserved map[string]bool
+}
+
+// Info - описание комнаты для списка комнат
+type Info struct {
+	ID        string    `json:"id"`
+	Players   int       `json:"players"`
+	Online    int       `json:"online"`
+	Tick      uint64    `json:"tick"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Presence - игрок комнаты для лобби. Игрок без соединения (в пределах
+// grace period) остается в комнате, но не Online. RTTMillis - сглаженный
+// ping соединения.
+type Presence struct {
+	PlayerID    string           `json:"player_id"`
+	UserID      string           `json:"user_id,omitempty"`
+	Room        string           `json:"room"`
+	Online      bool             `json:"online"`
+	ConnectedAt *time.Time       `json:"connected_at,omitempty"`
+	RTTMillis   float64          `json:"rtt_ms,omitempty"`
+	Stats       *network.Stats   `json:"stats,omitempty"`
+	Entity      *entities.Entity `json:"entity,omitempty"`
+}
+
+func newRoom(id string, engine *core.Engine, viewArea entities.Size) *Room {
+	r := &Room{
+		ID:        id,
+		Engine:    engine,
+		CreatedAt: time.Now(),
+		viewArea:  viewArea,
+		members:   make(map[string]bool),
+		clients:   network.NewRegistry(),
+	}
+	engine.SetBroadcaster(r)
+	engine.SetLatencySource(r)
+	r.clients.OnConnect(r.connectionEvent(core.CONNECTION_OPEN))
+	r.clients.OnDisconnect(r.connectionEvent(core.CONNECTION_CLOSE))
+
+	for _, t := range clientEvents {
+		engine.On(t, r.forwardEvent)
+	}
+	return r
+}
+
+// Admits сообщает, может ли игрок войти в комнату
+func (r *Room) Admits(playerID string) bool {
+	return r.reserved == nil || r.reserved[playerID]
+}
+
+// clientEvents - события движка, которые пересылаются клиентам комнаты.
+// Перемещения сюда не входят: их доставляют дельты.
+var clientEvents = []core.TEvent{
+	core.PLAYER_CONNECT,
+	core.PLAYER_DISCONNECT,
+	core.ENTITY_COLLISION,
+	core.TRIGGER_ENTER,
+	core.TRIGGER_EXIT,
+	core.HIT,
+	core.MISS,
+}
+
+func (r *Room) forwardEvent(event *core.Event) error {
+	data := ""
+	if event.Data != nil {
+		encoded, err := json.Marshal(event.Data)
+		if err != nil {
+			return err
+		}
+		data = string(encoded)
+	}
+
+	r.Send(protocol.BuildGameEvent(core.EventName(event.T), event.Entity, data, r.Engine.CurrentTick()))
+	return nil
+}
+
+// connectionEvent публикует в движке событие t о соединении
+func (r *Room) connectionEvent(t core.TEvent) func(*network.Client) {
+	return func(client *network.Client) {
+		event := core.NewEvent(t)
+		event.Entity = client.ID
+		event.Data = client.Info()
+		r.Engine.Emit(event)
+	}
+}
+
+// Send отправляет сообщение всем подключенным игрокам комнаты
+func (r *Room) Send(data []byte) {
+	r.clients.Range(func(client *network.Client) bool {
+		client.Send(data)
+		return true
+	})
+}
+
+// Connection возвращает соединение игрока, если оно подключено к комнате
+func (r *Room) Connection(playerID string) (*network.Client, bool) {
+	return r.clients.Get(playerID)
+}
+
+// Connections возвращает снимок соединений комнаты
+func (r *Room) Connections() []*network.Client {
+	return r.clients.All()
+}
+
+// RTT возвращает сглаженный RTT соединения игрока; 0 без соединения
+func (r *Room) RTT(playerID string) time.Duration {
+	client, ok := r.clients.Get(playerID)
+	if !ok {
+		return 0
+	}
+	return client.RTT()
+}
+
+// Shutdown отправляет подключенным игрокам причину остановки сервера и
+// закрывает их соединения после уже поставленных в очередь сообщений.
+// Возвращает закрываемых клиентов.
+func (r *Room) Shutdown(reason string) []*network.Client {
+	clients := r.clients.All()
+	message := protocol.BuildShutdown(reason)
+	for _, client := range clients {
+		client.CloseWith(message, websocket.CloseGoingAway, reason)
+	}
+	return clients
+}
+
+// enter добавляет игрока в комнату и создает его сущность
+func (r *Room) enter(playerID, owner string) {
+	r.mut.Lock()
+	r.members[playerID] = true
+	r.mut.Unlock()
+
+	r.Engine.PlayerConnected(playerID, owner)
+}
+
+// exit удаляет игрока и его сущность из комнаты и закрывает соединение,
+// если оно еще открыто. Возвращает число оставшихся игроков.
+func (r *Room) exit(playerID string) int {
+	r.mut.Lock()
+	delete(r.members, playerID)
+	left := len(r.members)
+	r.mut.Unlock()
+
+	if client := r.clients.RemoveID(playerID); client != nil {
+		client.Close()
+	}
+	r.Engine.PlayerDisconnected(playerID)
+	return left
+}
+
+// Attach подключает соединение игрока к рассылке комнаты. Предыдущее
+// соединение того же игрока закрывается.
+func (r *Room) Attach(client *network.Client) {
+	client.Room = r.ID
+	if previous := r.clients.Add(client); previous != nil {
+		previous.Close()
+	}
+}
+
+// Detach отключает соединение от рассылки, если оно все еще текущее
+// для своего игрока. Сущность игрока остается в мире.
+func (r *Room) Detach(client *network.Client) {
+	r.clients.Remove(client)
+}
+
+// Info возвращает описание комнаты
+func (r *Room) Info() Info {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return Info{
+		ID:        r.ID,
+		Players:   len(r.members),
+		Online:    r.clients.Len(),
+		Tick:      r.Engine.CurrentTick(),
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+// presence вызывается под блокировкой комнаты
+func (r *Room) presence(playerID string) Presence {
+	p := Presence{
+		PlayerID: playerID,
+		Room:     r.ID,
+		Entity:   r.Engine.EntityManager.GetByName(playerID),
+	}
+	if p.Entity != nil {
+		p.UserID = p.Entity.Owner
+	}
+	if client, ok := r.clients.Get(playerID); ok {
+		info := client.Info()
+		p.Online = true
+		p.ConnectedAt = &info.ConnectedAt
+		p.RTTMillis = info.RTTMillis
+		p.Stats = &info.Stats
+	}
+	return p
+}
+
+// Presence возвращает состояние игрока в комнате и его сущность
+func (r *Room) Presence(playerID string) (Presence, bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if !r.members[playerID] {
+		return Presence{}, false
+	}
+	return r.presence(playerID), true
+}
+
+// Online возвращает игроков комнаты с открытым соединением
+func (r *Room) Online() []Presence {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	clients := r.clients.All()
+	result := make([]Presence, 0, len(clients))
+	for _, client := range clients {
+		result = append(result, r.presence(client.ID))
+	}
+	return result
+}
+
+// Broadcast отправляет новым клиентам снапшот их зоны видимости, а
+// остальным - дельту изменений за тик относительно того, что они уже
+// получили
+func (r *Room) Broadcast(tick uint64, updates []entities.EntityUpdate) {
+	for _, client := range r.clients.All() {
+		ackSeq := r.Engine.LastProcessedInput(client.ID)
+		if client.Synced && len(updates) == 0 && !client.NeedsUpdate(ackSeq) {
+			continue
+		}
+
+		visible := network.VisibleEntities(r.Engine.EntityManager, client.ID, r.viewArea)
+
+		if !client.Synced {
+			client.Send(client.BuildSnapshot(tick, visible, ackSeq))
+			client.Synced = true
+			continue
+		}
+
+		if data := client.BuildDelta(tick, updates, visible, ackSeq); data != nil {
+			client.Send(data)
+		}
+	}
+}
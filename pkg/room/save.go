@@ -0,0 +1,45 @@
+package room
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"game_web_server/pkg/entities"
+)
+
+// Save - состояние мира комнаты, сохраненное при остановке сервера
+type Save struct {
+	Room     string            `json:"room"`
+	Tick     uint64            `json:"tick"`
+	SavedAt  time.Time         `json:"saved_at"`
+	Entities []entities.Entity `json:"entities"`
+}
+
+// Save записывает сущности комнаты в dir/<id>.json. Вызывать после
+// остановки движка, чтобы мир не менялся во время записи.
+func (r *Room) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(Save{
+		Room:     r.ID,
+		Tick:     r.Engine.CurrentTick(),
+		SavedAt:  time.Now(),
+		Entities: r.Engine.EntityManager.All(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// пишем во временный файл, чтобы прерванная запись не испортила
+	// предыдущее сохранение
+	path := filepath.Join(dir, url.PathEscape(r.ID)+".json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
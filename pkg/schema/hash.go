@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Hash считает хэш всех .fbs файлов в корне fsys. Файлы берутся по
+// имени в алфавитном порядке, переводы строк приводятся к \n, поэтому хэш
+// не зависит от ОС, на которой схемы были сгенерированы.
+func Hash(fsys fs.FS) (string, error) {
+	names, err := fs.Glob(fsys, "*")
+	if err != nil {
+		return "", err
+	}
+
+	var files []string
+	for _, name := range names {
+		if strings.EqualFold(path.Ext(name), ".fbs") {
+			files = append(files, name)
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no .fbs files found")
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, name := range files {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return "", err
+		}
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+		fmt.Fprintf(hash, "%s\n%d\n", name, len(data))
+		hash.Write(data)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// HashDir считает Hash для схем в директории dir
+func HashDir(dir string) (string, error) {
+	return Hash(os.DirFS(dir))
+}
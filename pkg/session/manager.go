@@ -0,0 +1,164 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultGracePeriod - сколько сессия живет после обрыва соединения
+const DefaultGracePeriod = 30 * time.Second
+
+// Session связывает токен клиента с постоянным идентификатором игрока
+type Session struct {
+	Token          string
+	PlayerID       string
+	UserID         string
+	Connected      bool
+	DisconnectedAt time.Time
+	generation     uint64
+}
+
+// ExpireCallback вызывается, когда игрок не вернулся за grace period
+type ExpireCallback = func(playerID string)
+
+// Manager выдает токены сессий и держит отключившихся игроков до
+// истечения grace period
+type Manager struct {
+	mut      sync.Mutex
+	grace    time.Duration
+	sessions map[string]*Session
+	onExpire []ExpireCallback
+}
+
+// NewManager создает менеджер сессий с заданным grace period
+func NewManager(grace time.Duration) *Manager {
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+
+	return &Manager{
+		grace:    grace,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// OnExpire регистрирует callback на окончательное удаление сессии
+func (m *Manager) OnExpire(callback ExpireCallback) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.onExpire = append(m.onExpire, callback)
+}
+
+func newToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Connect возобновляет сессию по токену или создает новую, если токен
+// пустой, неизвестный, уже истек или принадлежит другому пользователю.
+// Второе значение - была ли сессия возобновлена. Если по токену уже есть
+// активное соединение, новое соединение забирает сессию себе.
+func (m *Manager) Connect(token, userID string) (Session, bool) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	s, resumed := m.sessions[token]
+	if resumed && s.UserID != userID {
+		resumed = false
+	}
+	if !resumed {
+		s = &Session{
+			Token:    newToken(),
+			PlayerID: uuid.New().String(),
+			UserID:   userID,
+		}
+		m.sessions[s.Token] = s
+	}
+
+	s.Connected = true
+	s.DisconnectedAt = time.Time{}
+	s.generation++
+	return *s, resumed
+}
+
+// Disconnect отмечает обрыв соединения и запускает отсчет grace period.
+// Вызов от соединения, у которого сессию уже забрали, игнорируется.
+func (m *Manager) Disconnect(s Session) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	current, ok := m.sessions[s.Token]
+	if !ok || current.generation != s.generation {
+		return
+	}
+
+	current.Connected = false
+	current.DisconnectedAt = time.Now()
+	generation := current.generation
+
+	time.AfterFunc(m.grace, func() {
+		m.expire(s.Token, generation)
+	})
+}
+
+func (m *Manager) expire(token string, generation uint64) {
+	m.mut.Lock()
+	s, ok := m.sessions[token]
+	if !ok || s.Connected || s.generation != generation {
+		m.mut.Unlock()
+		return
+	}
+
+	callbacks := m.remove(token)
+	m.mut.Unlock()
+
+	for _, callback := range callbacks {
+		callback(s.PlayerID)
+	}
+}
+
+// End завершает сессию сразу, не дожидаясь grace period, - когда игрок
+// сам выходит. Вызываются те же callback, что и при истечении.
+func (m *Manager) End(token string) (Session, bool) {
+	m.mut.Lock()
+	s, ok := m.sessions[token]
+	if !ok {
+		m.mut.Unlock()
+		return Session{}, false
+	}
+
+	callbacks := m.remove(token)
+	m.mut.Unlock()
+
+	for _, callback := range callbacks {
+		callback(s.PlayerID)
+	}
+	return *s, true
+}
+
+// remove удаляет сессию и возвращает callback, которые нужно вызвать уже
+// без блокировки
+func (m *Manager) remove(token string) []ExpireCallback {
+	delete(m.sessions, token)
+	return append([]ExpireCallback(nil), m.onExpire...)
+}
+
+// Get возвращает сессию по токену
+func (m *Manager) Get(token string) (Session, bool) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	s, ok := m.sessions[token]
+	if !ok {
+		return Session{}, false
+	}
+	return *s, true
+}
@@ -0,0 +1,8 @@
+// Package schemes встраивает FlatBuffer схемы протокола в бинарник, чтобы
+// клиент и сервер могли сравнить хэш схем, из которых они собраны
+package schemes
+
+import "embed"
+
+//go:embed *.fbs
+var Files embed.FS
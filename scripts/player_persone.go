@@ -4,24 +4,19 @@ package main
 import (
 	"fmt"
 	"game_web_server/pkg/core"
-	"game_web_server/pkg/entities"
 )
 
-func ActionCallback(e *core.Engine) error {
+func ActionCallback(e *core.Engine, userAction *core.Action) error {
 	fmt.Println("Event name in file: player_persone.go")
 
-	//тут научить брать текущию позицию entity в кординатах по оси x, y
-	var entityName = "player_1"
+	var entityName = userAction.PlayerID
 	playerEntity := e.EntityManager.GetByName(entityName)
 	if playerEntity == nil {
 		fmt.Println("Entity is nil: ", entityName)
 		return nil
 	}
 
-	e.EntityManager.SetPosition(entityName, entities.Position{
-		X: playerEntity.Position.X + 5,
-		Y: playerEntity.Position.Y + 5,
-	})	
+	e.Shoot(userAction, core.DefaultShotRange)
 
 	return nil
 }
@@ -37,18 +32,52 @@ func Start(e *core.Engine) {
 		}
 	}()
 
-	var actionName = "player_gun"
-	chanSub := e.Subscribe(actionName)
+	e.On(core.PLAYER_CONNECT, func(event *core.Event) error {
+		fmt.Println("Player connected ---->", event.Entity, "resumed:", event.Data)
+		return nil
+	})
 
-	go func() {
-		for userAction := range chanSub {
-			fmt.Println("Action detect -> ", userAction.Name)
+	e.On(core.PLAYER_DISCONNECT, func(event *core.Event) error {
+		fmt.Println("Player disconnected ---->", event.Entity)
+		return nil
+	})
 
-			ActionCallback(e)
-		}
-	}()
+	e.On(core.CONNECTION_OPEN, func(event *core.Event) error {
+		fmt.Println("Connection opened ---->", event.Entity)
+		return nil
+	})
+
+	e.On(core.CONNECTION_CLOSE, func(event *core.Event) error {
+		fmt.Println("Connection closed ---->", event.Entity, event.Data)
+		return nil
+	})
 
-	// var playerGunAction = e.NewAction(actionName, ActionCallback)
-	// e.RegisterAction(playerGunAction)
+	e.On(core.ENTITY_COLLISION, func(event *core.Event) error {
+		fmt.Println("Collision ---->", event.Entity, "with", event.Data)
+		return nil
+	})
+
+	e.On(core.HIT, func(event *core.Event) error {
+		hit := event.Data.(*core.RayHit)
+		fmt.Println("Hit ---->", event.Entity, "hit", hit.Target.Name, "at", hit.Point, "tick", hit.Tick)
+		return nil
+	})
+
+	e.On(core.MISS, func(event *core.Event) error {
+		fmt.Println("Miss ---->", event.Entity)
+		return nil
+	})
+
+	var actionName = "player_gun"
+	var playerGunAction = e.NewAction(actionName, func(userAction *core.Action) error {
+		fmt.Println("Action detect -> ", userAction.Name)
+
+		return ActionCallback(e, userAction)
+	})
+	e.RegisterAction(playerGunAction)
+}
 
+// Stop вызывается при остановке движка комнаты
+func Stop(e *core.Engine) {
+	fmt.Println("Plugin player_persone stopped at tick", e.CurrentTick())
 }